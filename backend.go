@@ -0,0 +1,13 @@
+package blink
+
+// 原生调用后端，Blink 的所有 miniblink 导出函数调用都经由此接口分发
+//
+// 默认使用加载 DLL 的实现，测试时可以替换为 pkg/fakebackend 之类的内存实现
+type NativeBackend interface {
+	// 调用导出函数
+	Call(funcName string, args ...uintptr) (r1 uintptr, r2 uintptr, err error)
+	// 将 GO 函数转换为可以传给导出函数的回调指针
+	NewCallback(fn interface{}) uintptr
+	// 释放后端占用的资源
+	Release() error
+}
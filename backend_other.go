@@ -0,0 +1,10 @@
+//go:build !windows && !blink_tsgen
+
+package blink
+
+import "errors"
+
+// 非 windows 平台无法加载 miniblink DLL，需通过 WithNativeBackend 指定后端，如 pkg/fakebackend
+func newDefaultBackend(config *Config) (NativeBackend, error) {
+	return nil, errors.New("miniblink 仅支持 windows，其他平台请通过 WithNativeBackend 指定原生调用后端")
+}
@@ -0,0 +1,10 @@
+//go:build blink_tsgen
+
+package blink
+
+import "github.com/epkgs/blink/pkg/fakebackend"
+
+// 生成 TypeScript 声明时不加载 miniblink DLL，原生调用均返回 0，见 cmd/blink-tsgen
+func newDefaultBackend(config *Config) (NativeBackend, error) {
+	return fakebackend.New(), nil
+}
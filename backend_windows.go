@@ -0,0 +1,71 @@
+//go:build windows && !blink_tsgen
+
+package blink
+
+import (
+	"github.com/epkgs/blink/internal/log"
+	"github.com/epkgs/blink/internal/miniblink"
+	"golang.org/x/sys/windows"
+)
+
+// 加载 miniblink DLL 作为默认后端
+func newDefaultBackend(config *Config) (NativeBackend, error) {
+	dll, err := miniblink.LoadDLL(config.GetDllFile(), config.GetTempPath())
+	if err != nil {
+		return nil, err
+	}
+	return newDLLBackend(dll), nil
+}
+
+// 基于 miniblink DLL 的默认后端
+type dllBackend struct {
+	dll   *windows.DLL
+	procs map[string]*windows.Proc
+}
+
+func newDLLBackend(dll *windows.DLL) *dllBackend {
+	return &dllBackend{
+		dll:   dll,
+		procs: make(map[string]*windows.Proc),
+	}
+}
+
+func (b *dllBackend) findProc(name string) *windows.Proc {
+	proc, ok := b.procs[name]
+	if !ok {
+		proc = b.dll.MustFindProc(name)
+		b.procs[name] = proc
+	}
+	return proc
+}
+
+func (b *dllBackend) Call(funcName string, args ...uintptr) (r1 uintptr, r2 uintptr, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+
+			if r == windows.NOERROR {
+				err = nil
+				return
+			}
+
+			err = r.(error)
+			log.Error("Panic by CallFunc: %s", err.Error())
+		}
+	}()
+
+	r1, r2, err = b.findProc(funcName).Call(args...)
+
+	if err == windows.NOERROR {
+		err = nil
+	}
+
+	return
+}
+
+func (b *dllBackend) NewCallback(fn interface{}) uintptr {
+	return CallbackToPtr(fn)
+}
+
+func (b *dllBackend) Release() error {
+	return b.dll.Release()
+}
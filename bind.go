@@ -0,0 +1,163 @@
+package blink
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/epkgs/blink/internal/cast"
+	"github.com/epkgs/blink/internal/log"
+)
+
+var bindNameRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+var lockerMethods = map[string]bool{
+	"Lock": true, "Unlock": true, "TryLock": true,
+	"RLock": true, "RUnlock": true, "TryRLock": true, "RLocker": true,
+}
+
+// 绑定字段 getter/setter 时，window 上使用的属性名前缀
+const JS_BIND_PREFIX = "__mb_bind_"
+
+// 将 GO 对象绑定到页面的 window[name]
+//
+//   - 每个导出方法注册为 IPC handler，channel 为 `name.方法名`，页面中通过 window[name].方法名(...) 调用并返回 Promise，参数、返回值规则同 IPC.Handle
+//   - obj 为结构体指针时，导出字段（按 json tag 命名，`json:"-"` 忽略）可在页面中同步读写；为结构体时字段只读
+//   - obj 实现了 sync.Locker 时，页面读写字段时会在 miniblink 线程中加锁。
+//     因此方法或其他 goroutine 持有该锁时，不能调用需要等待 miniblink 线程的函数（如 RunJs、Eval、WaitFor 及其他 CallFunc），
+//     否则页面恰好读写字段时会互相等待而死锁；应先解锁，或在锁内复制需要的数据后再调用
+//
+// 需要在创建 view 之前调用
+func (mb *Blink) Bind(name string, obj interface{}) error {
+	if !bindNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid bind name: %q", name)
+	}
+	if name == JS_IPC || name == JS_MB {
+		return fmt.Errorf("bind name %q is reserved", name)
+	}
+	if obj == nil {
+		return fmt.Errorf("bind %s: obj is nil", name)
+	}
+
+	objVal := reflect.ValueOf(obj)
+	objType := objVal.Type()
+
+	locker, isLocker := obj.(sync.Locker)
+
+	// 方法
+	methods := make([]string, 0, objType.NumMethod())
+	for i := 0; i < objType.NumMethod(); i++ {
+		method := objType.Method(i)
+		if isLocker && lockerMethods[method.Name] {
+			continue // 嵌入的 sync.Mutex/RWMutex 的方法不暴露给页面
+		}
+		mb.IPC.Handle(name+"."+method.Name, objVal.Method(i).Interface())
+		methods = append(methods, method.Name)
+	}
+
+	// 字段
+	structVal := objVal
+	if structVal.Kind() == reflect.Ptr && !structVal.IsNil() {
+		structVal = structVal.Elem()
+	}
+
+	fields := make(map[string]string)
+	if structVal.Kind() == reflect.Struct {
+		writable := objVal.Kind() == reflect.Ptr
+
+		for _, field := range cast.JSONFields(structVal.Type()) {
+			native := JS_BIND_PREFIX + name + "_" + field.Name
+			if !bindNameRegexp.MatchString(native) {
+				log.Error("bind %s: field %s is not a valid js identifier, ignored", name, field.Name)
+				continue
+			}
+
+			mb.bindFieldGetter(native, structVal, field, locker)
+			if writable {
+				mb.bindFieldSetter(native, structVal, field, locker)
+			}
+			fields[field.Name] = native
+		}
+	}
+
+	mb.AddBootScript(bindScript(name, methods, fields))
+
+	return nil
+}
+
+func (mb *Blink) bindFieldGetter(native string, structVal reflect.Value, field cast.Field, locker sync.Locker) {
+	mb.js.bindGetter(native, func(es JsExecState) (value JsValue) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("bind getter %s: %v", native, r)
+				value = mb.js.Undefined()
+			}
+		}()
+
+		// 在 miniblink 线程中等待锁，持锁方不能同时等待 miniblink 线程，见 Bind 的说明
+		if locker != nil {
+			locker.Lock()
+			defer locker.Unlock()
+		}
+
+		fv, ok := cast.FieldValue(structVal, field.Index)
+		if !ok {
+			return mb.js.Undefined()
+		}
+
+		return mb.js.ToJsValue(es, fv.Interface())
+	})
+}
+
+func (mb *Blink) bindFieldSetter(native string, structVal reflect.Value, field cast.Field, locker sync.Locker) {
+	mb.js.bindSetter(native, func(es JsExecState, value JsValue) (result JsValue) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = mb.js.ThrowException(es, fmt.Sprintf("set %s: %v", field.Name, r))
+			}
+		}()
+
+		decoded, err := cast.Decode(field.Type, mb.js.ToGoValue(es, value))
+		if err != nil {
+			return mb.js.ThrowException(es, fmt.Sprintf("set %s: %s", field.Name, err.Error()))
+		}
+
+		// 在 miniblink 线程中等待锁，持锁方不能同时等待 miniblink 线程，见 Bind 的说明
+		if locker != nil {
+			locker.Lock()
+			defer locker.Unlock()
+		}
+
+		if fv, ok := cast.FieldValueAlloc(structVal, field.Index); ok {
+			fv.Set(decoded)
+		}
+		return mb.js.Undefined()
+	})
+}
+
+// 在页面中创建 window[name]，方法通过 IPC 调用，字段代理到原生的 getter/setter
+func bindScript(name string, methods []string, fields map[string]string) string {
+	nameTxt, _ := json.Marshal(name)
+	methodsTxt, _ := json.Marshal(methods)
+	fieldsTxt, _ := json.Marshal(fields)
+
+	return fmt.Sprintf(`(() => {
+		const name = %s;
+		const ns = window[name] = window[name] || {};
+		for (const method of %s) {
+			ns[method] = (...args) => window.top['%s'].invoke(name + '.' + method, ...args);
+		}
+		const fields = %s;
+		for (const field of Object.keys(fields)) {
+			const native = fields[field];
+			Object.defineProperty(ns, field, {
+				get: () => window[native],
+				set: (value) => { window[native] = value },
+				enumerable: true,
+				configurable: true,
+			});
+		}
+	})()`, nameTxt, methodsTxt, JS_IPC, fieldsTxt)
+}
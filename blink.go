@@ -8,14 +8,12 @@ import (
 	"unsafe"
 
 	"github.com/epkgs/blink/internal/log"
-	"github.com/epkgs/blink/internal/miniblink"
 	"github.com/epkgs/blink/pkg/alert"
 	"github.com/epkgs/blink/pkg/downloader"
 	"github.com/epkgs/blink/pkg/queue"
 	"github.com/epkgs/blink/pkg/resource"
+	"github.com/epkgs/blink/pkg/urlfilter"
 	"github.com/epkgs/blink/pkg/utils"
-	"github.com/lxn/win"
-	"golang.org/x/sys/windows"
 )
 
 var locker sync.RWMutex
@@ -50,9 +48,9 @@ type Blink struct {
 	js *JS
 
 	Resource *resource.Resource
+	Filter   *urlfilter.Filter // 作用于所有 view 的请求过滤规则
 
-	dll   *windows.DLL
-	procs map[string]*windows.Proc
+	backend NativeBackend
 
 	views   map[WkeHandle]*View
 	windows map[WkeHandle]*Window
@@ -78,11 +76,14 @@ func NewApp(setups ...func(*Config)) *Blink {
 		panic(err)
 	}
 
-	dll, err := miniblink.LoadDLL(config.GetDllFile(), config.GetTempPath())
-	if err != nil {
-		log.Error("loadDLL ERR: %v", err)
-		alert.Error(err.Error())
-		panic(err)
+	backend := config.backend
+	if backend == nil {
+		backend, err = newDefaultBackend(config)
+		if err != nil {
+			log.Error("loadDLL ERR: %v", err)
+			alert.Error(err.Error())
+			panic(err)
+		}
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -90,9 +91,9 @@ func NewApp(setups ...func(*Config)) *Blink {
 	blink := &Blink{
 		Config:   config,
 		Resource: resource.New(),
+		Filter:   urlfilter.New(),
 
-		dll:   dll,
-		procs: make(map[string]*windows.Proc),
+		backend: backend,
 
 		views:   make(map[WkeHandle]*View),
 		windows: make(map[WkeHandle]*Window),
@@ -112,6 +113,14 @@ func NewApp(setups ...func(*Config)) *Blink {
 		blink.initialize()
 	}
 
+	if config.highDPI {
+		_, _, _ = blink.CallFunc("wkeEnableHighDPISupport")
+	}
+
+	if proxy, ok := config.GetProxy(); ok {
+		blink.setProxy(proxy)
+	}
+
 	blink.js = newJS(blink)
 
 	blink.IPC = newIPC(blink)
@@ -133,7 +142,7 @@ func (mb *Blink) Exit() {
 
 	mb.finalize()
 
-	_ = mb.dll.Release()
+	_ = mb.backend.Release()
 	mb = nil
 }
 
@@ -170,46 +179,34 @@ func (mb *Blink) GetWindowByHandle(windowHwnd WkeHandle) (window *Window, exist
 	return
 }
 
-var winMsgOnce sync.Once
-
-func (mb *Blink) LoopWinMessage() {
-	winMsgOnce.Do(func() {
-
-		msg := &win.MSG{}
-
-		mb.AddLoop(func() {
-
-			if win.GetMessage(msg, 0, 0, 0) <= 0 {
-				return
-			}
-
-			win.TranslateMessage(msg)
-
-			win.DispatchMessage(msg)
-
-		})
-	})
-}
-
 func (mb *Blink) KeepRunning() {
 
+	// 以 blink_tsgen 构建（由 cmd/blink-tsgen 启动）时，仅生成 IPC 的 TypeScript 声明，不进入主循环
+	if generated, err := mb.IPC.generateTypeScript(); generated {
+		if err != nil {
+			log.Error("生成 TypeScript 声明失败: %s", err.Error())
+		}
+		return
+	}
+
 	mb.LoopWinMessage()
 
 	<-mb.Ctx.Done()
 }
 
-func (mb *Blink) findProc(name string) *windows.Proc {
-	proc, ok := mb.procs[name]
-	if !ok {
-		proc = mb.dll.MustFindProc(name)
-		mb.procs[name] = proc
-	}
-	return proc
+// 当前使用的原生调用后端
+func (mb *Blink) Backend() NativeBackend {
+	return mb.backend
+}
+
+// 将 GO 函数转换为回调指针，供 miniblink 的 wkeOnXXX 等接口使用
+func (mb *Blink) NewCallback(fn interface{}) uintptr {
+	return mb.backend.NewCallback(fn)
 }
 
 func (mb *Blink) CallFunc(funcName string, args ...uintptr) (r1 uintptr, r2 uintptr, err error) {
 
-	threadID := windows.GetCurrentThreadId()
+	threadID := currentThreadID()
 
 	// 如果和调用 MB 的线程不一致，则塞入 chan 队列，等待执行
 	if mb.threadID != threadID {
@@ -222,7 +219,7 @@ func (mb *Blink) CallFunc(funcName string, args ...uintptr) (r1 uintptr, r2 uint
 
 func (mb *Blink) CallFuncFirst(funcName string, args ...uintptr) (r1 uintptr, r2 uintptr, err error) {
 
-	threadID := windows.GetCurrentThreadId()
+	threadID := currentThreadID()
 
 	// 如果和调用 MB 的线程不一致，则塞入 chan 队列，等待执行
 	if mb.threadID != threadID {
@@ -276,11 +273,14 @@ func (mb *Blink) AddLoop(job ...func()) *Blink {
 
 func (mb *Blink) loopJobLoops() {
 
+	started := make(chan struct{})
+
 	utils.Go(func() {
 
 		runtime.LockOSThread() // ! 由于 miniblink 的线程限制，需要锁定线程
 
-		mb.threadID = windows.GetCurrentThreadId()
+		mb.threadID = currentThreadID()
+		close(started) // threadID 确定后才能开始调用 CallFunc
 
 		for {
 			select {
@@ -313,29 +313,12 @@ func (mb *Blink) loopJobLoops() {
 			}
 		}
 	}, nil)
+
+	<-started
 }
 
 func (mb *Blink) doCallFunc(name string, args ...uintptr) (r1 uintptr, r2 uintptr, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-
-			if r == windows.NOERROR {
-				err = nil
-				return
-			}
-
-			err = r.(error)
-			log.Error("Panic by CallFunc: %s", err.Error())
-		}
-	}()
-
-	r1, r2, err = mb.findProc(name).Call(args...)
-
-	if err == windows.NOERROR {
-		err = nil
-	}
-
-	return
+	return mb.backend.Call(name, args...)
 }
 
 func (mb *Blink) Version() int {
@@ -443,6 +426,17 @@ func (mb *Blink) NetHookRequest(job WkeNetJob) {
 	_, _, _ = mb.CallFunc("wkeNetHookRequest", uintptr(job))
 }
 
+// 注册自定义 scheme，如 app://host/path，请求与 Resource 走相同的拦截流程，可直接用于 View.LoadURL
+//
+// handler 为 nil 时按 host 交给 Resource.Bind / Resource.Handle 绑定的域名处理，如 app://local/index.html
+//
+// 不支持将 scheme 注册为 secure / standard：miniblink 未开放 Blink 的 SchemeRegistry，原因见 resource.SchemeConfig。
+// localStorage 等以 miniblink 的默认行为为准，需要 secure context 时请使用 Resource.Bind 绑定 https 域名；
+// 跨域访问可通过 resource.WithCORS 开启
+func (mb *Blink) RegisterScheme(scheme string, handler http.Handler, withConfig ...func(*resource.SchemeConfig)) error {
+	return mb.Resource.HandleScheme(scheme, handler, withConfig...)
+}
+
 func (mb *Blink) GetViewByJsExecState(es JsExecState) (view *View, exist bool) {
 	handle := mb.js.GetWebView(es)
 	return mb.GetViewByHandle(handle)
@@ -0,0 +1,173 @@
+package blink_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/fakebackend"
+)
+
+const testViewHandle = 0x1001
+
+func newTestApp(t *testing.T) (*blink.Blink, *fakebackend.Backend) {
+	t.Helper()
+
+	return newTestAppWithBackend(t, fakebackend.New())
+}
+
+// 使用预先设置好的 fake 创建 app，用于处理 NewApp 期间的原生调用，如 wkeJsBindFunction
+func newTestAppWithBackend(t *testing.T, fake *fakebackend.Backend) (*blink.Blink, *fakebackend.Backend) {
+	t.Helper()
+
+	fake.Return("wkeCreateWebWindow", testViewHandle)
+
+	app := blink.NewApp(
+		blink.WithNativeBackend(fake),
+		blink.WithTempPath(t.TempDir()),
+	)
+	t.Cleanup(app.CancelCtx)
+
+	return app, fake
+}
+
+// 触发 wkeOnLoadUrlBegin
+func fireLoadUrlBegin(fake *fakebackend.Backend, url string, job blink.WkeNetJob) []uintptr {
+	return fake.FireView("wkeOnLoadUrlBegin", testViewHandle, fake.String(url), uintptr(job))
+}
+
+// 触发 wkeOnOtherLoad 的 WKE_DID_GET_RESPONSE_DETAILS，job 收到状态码为 code 的响应
+func fireResponseDetails(fake *fakebackend.Backend, job blink.WkeNetJob, code int) {
+	info := &blink.WkeTempCallbackInfo{
+		Job:                 job,
+		WillSendRequestInfo: &blink.WkeWillSendRequestInfo{HTTPResponseCode: int32(code)},
+	}
+	fake.FireView("wkeOnOtherLoad", testViewHandle, uintptr(blink.WKE_DID_GET_RESPONSE_DETAILS), uintptr(unsafe.Pointer(info)))
+	runtime.KeepAlive(info)
+}
+
+func TestNewAppUsesBackend(t *testing.T) {
+	app, fake := newTestApp(t)
+
+	if app.Backend() != fake {
+		t.Fatal("app does not use the given backend")
+	}
+	if !fake.Called("wkeInitialize") {
+		t.Fatal("wkeInitialize was not called")
+	}
+}
+
+func TestCallFuncFromGoroutines(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Return("wkeVersion", 0x20230101)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ver := app.Version(); ver != 0x20230101 {
+				t.Errorf("Version() = %#x", ver)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := fake.Calls("wkeVersion"); len(calls) != 20 {
+		t.Fatalf("wkeVersion called %d times, want 20", len(calls))
+	}
+}
+
+func TestCallFuncArgs(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup(blink.WithWebWindowSize(640, 480))
+
+	if view.Hwnd != testViewHandle {
+		t.Fatalf("view handle = %#x", view.Hwnd)
+	}
+
+	calls := fake.Calls("wkeCreateWebWindow")
+	if len(calls) != 1 {
+		t.Fatalf("wkeCreateWebWindow called %d times", len(calls))
+	}
+	if args := calls[0].Args; args[0] != uintptr(blink.WKE_WINDOW_TYPE_POPUP) || args[4] != 640 || args[5] != 480 {
+		t.Fatalf("wkeCreateWebWindow args = %v", args)
+	}
+
+	view.Resize(100, 200)
+
+	calls = fake.Calls("wkeResize")
+	if len(calls) != 1 || calls[0].Args[0] != testViewHandle || calls[0].Args[1] != 100 || calls[0].Args[2] != 200 {
+		t.Fatalf("wkeResize calls = %v", calls)
+	}
+}
+
+func TestViewEvents(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	var frames []blink.WkeWebFrameHandle
+	stop := view.OnDocumentReady(func(frame blink.WkeWebFrameHandle) {
+		frames = append(frames, frame)
+	})
+
+	fake.FireView("wkeOnDocumentReady2", testViewHandle, 7)
+	stop()
+	fake.FireView("wkeOnDocumentReady2", testViewHandle, 8)
+
+	if len(frames) != 1 || frames[0] != 7 {
+		t.Fatalf("frames = %v, want [7]", frames)
+	}
+}
+
+func TestOnLoadUrlBegin(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	var urls []string
+	view.OnLoadUrlBegin(func(url string, job blink.WkeNetJob) bool {
+		urls = append(urls, url)
+		return url == "http://blocked.test/"
+	})
+
+	results := fireLoadUrlBegin(fake, "http://allowed.test/", 1)
+	if len(results) != 1 || results[0] != 0 {
+		t.Fatalf("allowed request results = %v", results)
+	}
+
+	results = fireLoadUrlBegin(fake, "http://blocked.test/", 2)
+	if len(results) != 1 || results[0] != 1 {
+		t.Fatalf("blocked request results = %v", results)
+	}
+
+	if len(urls) != 2 || urls[0] != "http://allowed.test/" || urls[1] != "http://blocked.test/" {
+		t.Fatalf("urls = %v", urls)
+	}
+}
+
+func TestOnLoadUrlBeginOrder(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	var order []int
+	for i := 0; i < 20; i++ {
+		i := i
+		view.OnLoadUrlBegin(func(url string, job blink.WkeNetJob) bool {
+			order = append(order, i)
+			return false
+		})
+	}
+
+	fireLoadUrlBegin(fake, "http://order.test/", 1)
+
+	if len(order) != 20 {
+		t.Fatalf("order = %v", order)
+	}
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("callbacks run out of registration order: %v", order)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package blink
+
+import "syscall"
+
+// callback = func(args ...uintptr) uintptr
+func CallbackToPtr(callback interface{}) uintptr {
+	return syscall.NewCallbackCDecl(callback)
+}
@@ -0,0 +1,90 @@
+// blink-tsgen 为使用 blink 的程序生成 IPC 的 TypeScript 声明文件
+//
+// 以 go run -tags blink_tsgen 方式启动目标程序，程序在调用 KeepRunning 时将已注册的全局 GO handler 写为 .d.ts 后退出，
+// 因此 handler 需要在 KeepRunning 之前注册。该 tag 下不加载 miniblink DLL（原生调用均返回 0），可在任意平台生成
+//
+//	go run github.com/epkgs/blink/cmd/blink-tsgen -o web/src/ipc.d.ts ./cmd/myapp
+//
+// 可通过 go:generate 使用：
+//
+//	//go:generate go run github.com/epkgs/blink/cmd/blink-tsgen -o web/src/ipc.d.ts .
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/epkgs/blink/pkg/tsgen"
+)
+
+func main() {
+	out := flag.String("o", "ipc.d.ts", "声明文件的输出路径")
+	namespace := flag.String("ns", tsgen.DefaultNamespace, "声明使用的命名空间")
+	tags := flag.String("tags", "", "构建目标程序时使用的 build tags")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: blink-tsgen [flags] [package] [-- program args]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pkg := "."
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "--" {
+		pkg = args[0]
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if err := run(pkg, *out, *namespace, *tags, args); err != nil {
+		fmt.Fprintln(os.Stderr, "blink-tsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, out, namespace, tags string, args []string) error {
+	output, err := filepath.Abs(out)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return err
+	}
+
+	// 删除旧文件，用于判断目标程序是否生成了声明
+	if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	buildTags := tsgen.BuildTag
+	if tags != "" {
+		buildTags = tags + "," + buildTags
+	}
+
+	goArgs := []string{"run", "-tags", buildTags, pkg}
+	goArgs = append(goArgs, args...)
+
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		tsgen.EnvOutput+"="+output,
+		tsgen.EnvNamespace+"="+namespace,
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run %s: %w", pkg, err)
+	}
+
+	if _, err := os.Stat(output); err != nil {
+		return fmt.Errorf("%s did not generate declarations, make sure it calls KeepRunning after registering handlers", pkg)
+	}
+
+	fmt.Println("generated", output)
+	return nil
+}
@@ -2,8 +2,11 @@ package blink
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/epkgs/blink/internal/log"
 	dl "github.com/epkgs/blink/pkg/downloader"
@@ -21,6 +24,18 @@ type Config struct {
 	cookieFile string
 	// 默认下载器
 	Downloader *dl.Downloader
+	// IPC 默认超时时间，为 0 时不限制
+	ipcTimeout time.Duration
+	// 原生调用后端，为空时加载 miniblink DLL
+	backend NativeBackend
+	// 全局代理，为空时不设置
+	proxy *ProxyInfo
+	// 下载器使用的代理
+	downloaderProxy func(*http.Request) (*url.URL, error)
+	// view 的默认设置，在 NewView 时应用
+	viewSettings viewSettings
+	// 是否开启高分屏支持
+	highDPI bool
 }
 
 func NewConfig(setups ...func(*Config)) (*Config, error) {
@@ -32,6 +47,7 @@ func NewConfig(setups ...func(*Config)) (*Config, error) {
 		dllFile:     "blink.dll",
 		storagePath: "LocalStorage",
 		cookieFile:  "cookie.dat",
+		ipcTimeout:  10 * time.Second,
 	}
 
 	conf.Downloader = dl.New(func(c *dl.Config) {
@@ -46,6 +62,10 @@ func NewConfig(setups ...func(*Config)) (*Config, error) {
 		setup(conf)
 	}
 
+	if conf.downloaderProxy != nil && conf.Downloader != nil && conf.Downloader.Proxy == nil {
+		conf.Downloader.Proxy = conf.downloaderProxy
+	}
+
 	log.Debug("临时文件夹：%s", conf.tempPath)
 	if err := os.MkdirAll(conf.tempPath, 0644); err != nil {
 		return nil, fmt.Errorf("临时文件夹(%s)不存在，且创建不成功，请确认文件夹权限。", conf.tempPath)
@@ -84,10 +104,97 @@ func WithDownloader(downloader *dl.Downloader) func(*Config) {
 	}
 }
 
+// 设置 IPC 默认超时时间（Invoke、JS 端 ipc.invoke），为 0 时不限制
+func WithIPCTimeout(timeout time.Duration) func(*Config) {
+	return func(conf *Config) {
+		conf.ipcTimeout = timeout
+	}
+}
+
+// 使用指定的原生调用后端代替 miniblink DLL，常用于测试
+func WithNativeBackend(backend NativeBackend) func(*Config) {
+	return func(conf *Config) {
+		conf.backend = backend
+	}
+}
+
+// 设置全局代理，同时应用到默认下载器
+func WithProxy(info ProxyInfo) func(*Config) {
+	return func(conf *Config) {
+		conf.proxy = &info
+		conf.downloaderProxy = info.httpProxy()
+	}
+}
+
+// 按环境变量 HTTPS_PROXY、HTTP_PROXY、NO_PROXY 等设置代理，详见 ProxyFromEnvironment
+func WithProxyFromEnvironment() func(*Config) {
+	return func(conf *Config) {
+		info, ok := ProxyFromEnvironment()
+		if !ok {
+			return
+		}
+		conf.proxy = &info
+		conf.downloaderProxy = http.ProxyFromEnvironment
+	}
+}
+
+// 设置所有 view 默认的 UserAgent
+func WithUserAgent(userAgent string) func(*Config) {
+	return func(conf *Config) {
+		conf.viewSettings.userAgent = userAgent
+	}
+}
+
+// 设置所有 view 默认的 navigator.language，如 zh-CN
+func WithLanguage(language string) func(*Config) {
+	return func(conf *Config) {
+		conf.viewSettings.language = language
+	}
+}
+
+// 所有 view 默认模拟的设备，见 Devices。WithUserAgent 优先于设备的 UserAgent
+func WithDevice(device Device) func(*Config) {
+	return func(conf *Config) {
+		conf.viewSettings.device = &device
+	}
+}
+
+// 设置所有 view 默认的缩放比例
+func WithZoomFactor(factor float32) func(*Config) {
+	return func(conf *Config) {
+		conf.viewSettings.zoomFactor = factor
+	}
+}
+
+// 设置所有 view 是否检查 CSP
+func WithCSPCheck(enable bool) func(*Config) {
+	return func(conf *Config) {
+		conf.viewSettings.cspCheck = &enable
+	}
+}
+
+// 开启高分屏支持，按系统 DPI 缩放
+func WithHighDPI() func(*Config) {
+	return func(conf *Config) {
+		conf.highDPI = true
+	}
+}
+
+func (conf *Config) GetProxy() (ProxyInfo, bool) {
+	if conf.proxy == nil {
+		return ProxyInfo{}, false
+	}
+	return *conf.proxy, true
+}
+
 func (conf *Config) GetDllFile() string {
 	return conf.dllFile
 }
 
+func (conf *Config) GetIPCTimeout() time.Duration {
+	return conf.ipcTimeout
+}
+
 func (conf *Config) GetTempPath() string {
 	return conf.tempPath
 }
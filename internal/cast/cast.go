@@ -404,7 +404,7 @@ func ByteToBool(b byte) bool {
 	return b == 1
 }
 
-// 将map[string]interface{}转换为结构体
+// MapToStruct 将 map 按 json tag 解码到结构体，s 为结构体指针或可写的结构体 reflect.Value
 func MapToStruct(m map[string]interface{}, s interface{}) error {
 	structValue, ok := s.(reflect.Value)
 	if !ok {
@@ -415,57 +415,7 @@ func MapToStruct(m map[string]interface{}, s interface{}) error {
 		structValue = sValue.Elem()
 	}
 
-	structType := structValue.Type()
-
-	for i := 0; i < structValue.NumField(); i++ {
-		field := structValue.Field(i)
-		fieldType := structType.Field(i)
-
-		// 处理嵌套结构体
-		if fieldType.Type.Kind() == reflect.Struct {
-			// 如果字段是结构体，则递归调用mapToStruct进行填充
-			nestedStructValue := reflect.New(fieldType.Type).Elem()
-			if err := MapToStruct(m, nestedStructValue); err != nil {
-				return err
-			}
-			field.Set(nestedStructValue)
-		} else if fieldType.Type.Kind() == reflect.Ptr && fieldType.Type.Elem().Kind() == reflect.Struct {
-			// 处理指针类型的嵌套结构体
-			mapValue, ok := m[fieldType.Name]
-			if !ok {
-				continue // 字段不存在于map中，跳过
-			}
-
-			// 如果传入的参数是指针类型的map，则解引用取得实际的map[string]interface{}值
-			if reflect.TypeOf(mapValue).Kind() == reflect.Ptr {
-				mapValue = reflect.ValueOf(mapValue).Elem().Interface()
-			}
-
-			// 创建新的结构体实例并填充数据
-			nestedStructValue := reflect.New(fieldType.Type.Elem()).Elem()
-			if err := MapToStruct(mapValue.(map[string]interface{}), nestedStructValue); err != nil {
-				return err
-			}
-			// 将新创建的结构体指针赋值给字段
-			field.Set(reflect.New(fieldType.Type.Elem()))
-			field.Elem().Set(nestedStructValue)
-		} else {
-			// 处理普通字段
-			mapValue, ok := m[fieldType.Name]
-			if !ok {
-				continue // 字段不存在于map中，跳过
-			}
-
-			// 检查字段类型是否匹配
-			if reflect.TypeOf(mapValue).AssignableTo(fieldType.Type) {
-				field.Set(reflect.ValueOf(mapValue))
-			} else {
-				return fmt.Errorf("Type mismatch for field '%s'", fieldType.Name)
-			}
-		}
-	}
-
-	return nil
+	return decodeStruct(m, structValue, "")
 }
 
 // 将结构体转换为 map
@@ -494,125 +444,7 @@ func StructToMap(s interface{}) map[string]interface{} {
 	return result
 }
 
-// 调用 callback
-func Param(param reflect.Type, input interface{}) (reflectVal reflect.Value, err error) {
-
-	pKind := param.Kind()
-
-	switch pKind {
-	case reflect.String:
-		reflectVal = reflect.ValueOf(ToString(input))
-	case reflect.Int:
-		val, _ := ToNumber[int](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Int8:
-		val, _ := ToNumber[int8](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Int16:
-		val, _ := ToNumber[int16](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Int32:
-		val, _ := ToNumber[int32](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Int64:
-		val, _ := ToNumber[int64](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uint:
-		val, _ := ToNumber[uint](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uint8:
-		val, _ := ToNumber[uint8](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uint16:
-		val, _ := ToNumber[uint16](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uint32:
-		val, _ := ToNumber[uint32](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uint64:
-		val, _ := ToNumber[uint64](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Uintptr:
-		val, _ := ToNumber[uintptr](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Float32:
-		val, _ := ToNumber[float32](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Float64:
-		val, _ := ToNumber[float64](input)
-		reflectVal = reflect.ValueOf(val)
-	case reflect.Bool:
-		reflectVal = reflect.ValueOf(ToBool(input))
-	case reflect.Ptr:
-		if param.Elem().Kind() == reflect.Struct {
-			// 如果参数是指针类型的结构体，并且传入的是*map[string]interface{}，则进行转换
-			inputKind := reflect.TypeOf(input).Kind()
-			var m map[string]interface{}
-			if inputKind == reflect.Ptr {
-				m = *input.(*map[string]interface{})
-			} else {
-				m = input.(map[string]interface{})
-			}
-			reflectVal = reflect.New(param.Elem()).Elem()
-			if err = MapToStruct(m, reflectVal); err != nil {
-				return
-			}
-		} else {
-			// 仅支持 struct 指针
-			err = fmt.Errorf("failed to convert input to pointer")
-			return
-		}
-	case reflect.Struct:
-		inputKind := reflect.TypeOf(input).Kind()
-		if inputKind == reflect.Map {
-			// 如果参数是结构体且传入的是map[string]interface{}，则进行转换
-			reflectVal = reflect.New(param).Elem()
-			if err = MapToStruct(input.(map[string]interface{}), reflectVal); err != nil {
-				return
-			}
-		} else if inputKind == reflect.Ptr {
-			// 如果参数是指针类型的结构体，并且传入的是*map[string]interface{}，则进行转换
-			reflectVal = reflect.New(param).Elem()
-			if err = MapToStruct(*input.(*map[string]interface{}), reflectVal); err != nil {
-				return
-			}
-		} else if inputKind == reflect.Struct {
-			reflectVal = reflect.ValueOf(input)
-		} else {
-			err = fmt.Errorf("failed to convert input to struct")
-			return
-		}
-
-	case reflect.Map:
-		inputKind := reflect.TypeOf(input).Kind()
-		switch inputKind {
-		case reflect.Map:
-			// 如果参数是map，则直接使用
-			reflectVal = reflect.ValueOf(input)
-		case reflect.Ptr:
-			// 如果参数是指针类型，则解引用
-			reflectVal = reflect.ValueOf(input).Elem()
-		default:
-			err = fmt.Errorf("failed to convert input to map")
-			return
-		}
-	case reflect.Slice:
-		inputKind := reflect.TypeOf(input).Kind()
-		switch inputKind {
-		case reflect.Slice:
-			// 如果输入是slice，则直接使用
-			reflectVal = reflect.ValueOf(input)
-		case reflect.Ptr:
-			// 如果输入是指针类型，则解引用
-			reflectVal = reflect.ValueOf(input).Elem()
-		default:
-			err = fmt.Errorf("type mismatch, expect %v input %v(%v)", pKind, input, inputKind)
-			return
-		}
-
-	default:
-		reflectVal = reflect.ValueOf(input)
-	}
-
-	return
+// Param 将 IPC 传入的参数转为 handler 参数的类型，规则见 Decode
+func Param(param reflect.Type, input interface{}) (reflect.Value, error) {
+	return Decode(param, input)
 }
@@ -0,0 +1,542 @@
+package cast
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// 解码失败时的错误，Path 为出错值所在的位置，如 `[0].items[2].price`
+type DecodeError struct {
+	Path  string
+	Value interface{}
+	Type  reflect.Type
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "value"
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("cannot decode %s (%T) into %s: %s", path, e.Value, e.Type, e.Err.Error())
+	}
+	return fmt.Sprintf("cannot decode %s (%T) into %s", path, e.Value, e.Type)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// 将 input 解码为 t 类型的值
+//
+// input 通常为 JSON 解析结果（nil、bool、float64、string、[]interface{}、map[string]interface{}），也可以是任意 GO 值：
+//   - 结构体按 json tag 匹配字段（名称完全一致优先，其次忽略大小写），支持嵌入结构体及 string 选项
+//   - 数字之间相互转换，整数溢出、小数转整数时返回错误；数字字符串可转为数字
+//   - 支持 json.Unmarshaler、encoding.TextUnmarshaler，如 time.Time
+//   - 指针、切片、数组、map 会递归解码
+func Decode(t reflect.Type, input interface{}) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	if err := decodeValue(input, out, ""); err != nil {
+		return reflect.Value{}, err
+	}
+	return out, nil
+}
+
+// 将 input 解码到 ptr 指向的变量
+func DecodeInto(input interface{}, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", ptr)
+	}
+	return decodeValue(input, rv.Elem(), "")
+}
+
+func decodeValue(in interface{}, out reflect.Value, path string) error {
+	if in == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+
+	inVal := reflect.ValueOf(in)
+	outType := out.Type()
+
+	if inVal.Type().AssignableTo(outType) {
+		out.Set(inVal)
+		return nil
+	}
+
+	// 输入为指针时解引用
+	if inVal.Kind() == reflect.Ptr {
+		if inVal.IsNil() {
+			out.Set(reflect.Zero(outType))
+			return nil
+		}
+		return decodeValue(inVal.Elem().Interface(), out, path)
+	}
+
+	if outType.Kind() == reflect.Ptr {
+		elem := reflect.New(outType.Elem())
+		if err := decodeValue(in, elem.Elem(), path); err != nil {
+			return err
+		}
+		out.Set(elem)
+		return nil
+	}
+
+	if handled, err := decodeUnmarshaler(in, out, path); handled {
+		return err
+	}
+
+	switch outType.Kind() {
+	case reflect.Interface:
+		if outType.NumMethod() == 0 {
+			out.Set(inVal)
+			return nil
+		}
+	case reflect.Bool:
+		return decodeBool(in, out, path)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decodeInt(in, out, path)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return decodeUint(in, out, path)
+	case reflect.Float32, reflect.Float64:
+		return decodeFloat(in, out, path)
+	case reflect.String:
+		return decodeString(in, out, path)
+	case reflect.Slice:
+		return decodeSlice(in, out, path)
+	case reflect.Array:
+		return decodeArray(in, out, path)
+	case reflect.Map:
+		return decodeMap(in, out, path)
+	case reflect.Struct:
+		return decodeStruct(in, out, path)
+	}
+
+	return &DecodeError{Path: path, Value: in, Type: outType}
+}
+
+// json.Unmarshaler 使用输入的 JSON 编码调用，encoding.TextUnmarshaler 仅在输入为字符串时调用
+func decodeUnmarshaler(in interface{}, out reflect.Value, path string) (bool, error) {
+	if !out.CanAddr() {
+		return false, nil
+	}
+
+	ptrType := reflect.PtrTo(out.Type())
+
+	if ptrType.Implements(jsonUnmarshalerType) {
+		data, err := json.Marshal(in)
+		if err == nil {
+			err = out.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		}
+		if err != nil {
+			return true, &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+		return true, nil
+	}
+
+	if ptrType.Implements(textUnmarshalerType) {
+		s, ok := in.(string)
+		if !ok {
+			return false, nil
+		}
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return true, &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func decodeBool(in interface{}, out reflect.Value, path string) error {
+	inVal := reflect.ValueOf(in)
+	switch inVal.Kind() {
+	case reflect.Bool:
+		out.SetBool(inVal.Bool())
+		return nil
+	case reflect.String:
+		b, err := strconv.ParseBool(inVal.String())
+		if err != nil {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+		out.SetBool(b)
+		return nil
+	}
+	return &DecodeError{Path: path, Value: in, Type: out.Type()}
+}
+
+func decodeInt(in interface{}, out reflect.Value, path string) error {
+	var n int64
+
+	inVal := reflect.ValueOf(in)
+	switch inVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = inVal.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := inVal.Uint()
+		if u > math.MaxInt64 {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+		}
+		n = int64(u)
+	case reflect.Float32, reflect.Float64:
+		f := inVal.Float()
+		if f != math.Trunc(f) {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errFraction}
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+		}
+		n = int64(f)
+	case reflect.String:
+		var err error
+		if n, err = strconv.ParseInt(strings.TrimSpace(inVal.String()), 10, 64); err != nil {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+	default:
+		return &DecodeError{Path: path, Value: in, Type: out.Type()}
+	}
+
+	if out.OverflowInt(n) {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+	}
+	out.SetInt(n)
+	return nil
+}
+
+func decodeUint(in interface{}, out reflect.Value, path string) error {
+	var n uint64
+
+	inVal := reflect.ValueOf(in)
+	switch inVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := inVal.Int()
+		if i < 0 {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+		}
+		n = uint64(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = inVal.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := inVal.Float()
+		if f != math.Trunc(f) {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errFraction}
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+		}
+		n = uint64(f)
+	case reflect.String:
+		var err error
+		if n, err = strconv.ParseUint(strings.TrimSpace(inVal.String()), 10, 64); err != nil {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+	default:
+		return &DecodeError{Path: path, Value: in, Type: out.Type()}
+	}
+
+	if out.OverflowUint(n) {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+	}
+	out.SetUint(n)
+	return nil
+}
+
+func decodeFloat(in interface{}, out reflect.Value, path string) error {
+	var f float64
+
+	inVal := reflect.ValueOf(in)
+	switch inVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(inVal.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f = float64(inVal.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = inVal.Float()
+	case reflect.String:
+		var err error
+		if f, err = strconv.ParseFloat(strings.TrimSpace(inVal.String()), 64); err != nil {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+	default:
+		return &DecodeError{Path: path, Value: in, Type: out.Type()}
+	}
+
+	if out.OverflowFloat(f) {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errOverflow}
+	}
+	out.SetFloat(f)
+	return nil
+}
+
+// 字符串目标兼容数字、布尔值的输入
+func decodeString(in interface{}, out reflect.Value, path string) error {
+	inVal := reflect.ValueOf(in)
+	switch inVal.Kind() {
+	case reflect.String:
+		out.SetString(inVal.String())
+	case reflect.Bool:
+		out.SetString(strconv.FormatBool(inVal.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out.SetString(strconv.FormatInt(inVal.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		out.SetString(strconv.FormatUint(inVal.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		out.SetString(strconv.FormatFloat(inVal.Float(), 'f', -1, inVal.Type().Bits()))
+	case reflect.Slice:
+		if inVal.Type().Elem().Kind() != reflect.Uint8 {
+			return &DecodeError{Path: path, Value: in, Type: out.Type()}
+		}
+		out.SetString(string(inVal.Bytes()))
+	default:
+		return &DecodeError{Path: path, Value: in, Type: out.Type()}
+	}
+	return nil
+}
+
+func decodeSlice(in interface{}, out reflect.Value, path string) error {
+	outType := out.Type()
+	inVal := reflect.ValueOf(in)
+
+	// 与 encoding/json 一致，[]byte 可以由 base64 字符串解码
+	if outType.Elem().Kind() == reflect.Uint8 {
+		switch inVal.Kind() {
+		case reflect.String:
+			data, err := base64.StdEncoding.DecodeString(inVal.String())
+			if err != nil {
+				return &DecodeError{Path: path, Value: in, Type: outType, Err: err}
+			}
+			out.Set(reflect.ValueOf(data).Convert(outType))
+			return nil
+		case reflect.Slice, reflect.Array:
+			if inVal.Type().Elem().Kind() == reflect.Uint8 {
+				data := reflect.MakeSlice(outType, inVal.Len(), inVal.Len())
+				reflect.Copy(data, inVal)
+				out.Set(data)
+				return nil
+			}
+		}
+	}
+
+	if inVal.Kind() != reflect.Slice && inVal.Kind() != reflect.Array {
+		return &DecodeError{Path: path, Value: in, Type: outType}
+	}
+
+	slice := reflect.MakeSlice(outType, inVal.Len(), inVal.Len())
+	for i := 0; i < inVal.Len(); i++ {
+		if err := decodeValue(inVal.Index(i).Interface(), slice.Index(i), indexPath(path, i)); err != nil {
+			return err
+		}
+	}
+	out.Set(slice)
+	return nil
+}
+
+// 多余的元素忽略，不足的为零值
+func decodeArray(in interface{}, out reflect.Value, path string) error {
+	inVal := reflect.ValueOf(in)
+	if inVal.Kind() != reflect.Slice && inVal.Kind() != reflect.Array {
+		return &DecodeError{Path: path, Value: in, Type: out.Type()}
+	}
+
+	out.Set(reflect.Zero(out.Type()))
+	for i := 0; i < inVal.Len() && i < out.Len(); i++ {
+		if err := decodeValue(inVal.Index(i).Interface(), out.Index(i), indexPath(path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeMap(in interface{}, out reflect.Value, path string) error {
+	outType := out.Type()
+	inVal := reflect.ValueOf(in)
+	if inVal.Kind() != reflect.Map {
+		return &DecodeError{Path: path, Value: in, Type: outType}
+	}
+
+	m := reflect.MakeMapWithSize(outType, inVal.Len())
+	iter := inVal.MapRange()
+	for iter.Next() {
+		keyPath := fieldPath(path, fmt.Sprint(iter.Key().Interface()))
+
+		key := reflect.New(outType.Key()).Elem()
+		if err := decodeMapKey(iter.Key().Interface(), key, keyPath); err != nil {
+			return err
+		}
+
+		val := reflect.New(outType.Elem()).Elem()
+		if err := decodeValue(iter.Value().Interface(), val, keyPath); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, val)
+	}
+	out.Set(m)
+	return nil
+}
+
+// map 的键，JSON 中均为字符串
+func decodeMapKey(in interface{}, out reflect.Value, path string) error {
+	if s, ok := in.(string); ok && reflect.PtrTo(out.Type()).Implements(textUnmarshalerType) {
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: err}
+		}
+		return nil
+	}
+	return decodeValue(in, out, path)
+}
+
+func decodeStruct(in interface{}, out reflect.Value, path string) error {
+	outType := out.Type()
+	inVal := reflect.ValueOf(in)
+
+	// 其他类型的结构体，通过 JSON 转换
+	if inVal.Kind() == reflect.Struct {
+		data, err := json.Marshal(in)
+		if err == nil {
+			var m map[string]interface{}
+			if err = json.Unmarshal(data, &m); err == nil {
+				return decodeStruct(m, out, path)
+			}
+		}
+		return &DecodeError{Path: path, Value: in, Type: outType, Err: err}
+	}
+
+	if inVal.Kind() != reflect.Map || inVal.Type().Key().Kind() != reflect.String {
+		return &DecodeError{Path: path, Value: in, Type: outType}
+	}
+
+	fields := JSONFields(outType)
+
+	// map 的遍历顺序不固定，先为每个字段选出键：名称完全一致的优先，同为忽略大小写匹配时取最小的键
+	keys := make([]reflect.Value, len(fields))
+	iter := inVal.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+
+		i, ok := matchField(fields, key.String())
+		if !ok {
+			continue // 多余的键忽略
+		}
+
+		if prev := keys[i]; prev.IsValid() {
+			prevExact, exact := prev.String() == fields[i].Name, key.String() == fields[i].Name
+			if prevExact || (!exact && prev.String() < key.String()) {
+				continue
+			}
+		}
+		keys[i] = key
+	}
+
+	for i, field := range fields {
+		if !keys[i].IsValid() {
+			continue
+		}
+
+		fv, ok := FieldValueAlloc(out, field.Index)
+		if !ok {
+			continue // 未导出的嵌入结构体指针，无法赋值
+		}
+
+		decode := decodeValue
+		if field.Quoted {
+			decode = decodeQuoted
+		}
+		if err := decode(inVal.MapIndex(keys[i]).Interface(), fv, fieldPath(path, field.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 带 string 选项的字段，与 encoding/json 一致，输入为 JSON 编码后的字符串，如 `"1"`、`"\"abc\""`
+func decodeQuoted(in interface{}, out reflect.Value, path string) error {
+	if in == nil {
+		return decodeValue(nil, out, path)
+	}
+
+	s, ok := in.(string)
+	if !ok {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errQuoted}
+	}
+
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errQuoted}
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errQuoted}
+	}
+
+	kind := out.Kind()
+	if kind == reflect.Ptr {
+		kind = out.Type().Elem().Kind()
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return decodeValue(nil, out, path)
+	case string:
+		if kind == reflect.String {
+			return decodeValue(v, out, path)
+		}
+	case bool:
+		if kind == reflect.Bool {
+			return decodeValue(v, out, path)
+		}
+	case json.Number:
+		if kind != reflect.String && kind != reflect.Bool {
+			return decodeValue(v.String(), out, path)
+		}
+	}
+	return &DecodeError{Path: path, Value: in, Type: out.Type(), Err: errQuoted}
+}
+
+// 键对应的字段下标，名称完全一致优先，其次忽略大小写
+func matchField(fields []Field, key string) (int, bool) {
+	for i, f := range fields {
+		if f.Name == key {
+			return i, true
+		}
+	}
+	for i, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+var (
+	errOverflow = fmt.Errorf("value out of range")
+	errFraction = fmt.Errorf("value has a fractional part")
+	errQuoted   = fmt.Errorf("invalid use of ,string struct tag")
+)
@@ -0,0 +1,300 @@
+package cast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type celsius float64
+
+func (c *celsius) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	_, err := fmt.Sscanf(s, "%f°C", (*float64)(c))
+	return err
+}
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type Other struct {
+	Name string
+}
+
+type Tagged struct {
+	Name string `json:"Name"`
+}
+
+type embedded struct {
+	Base
+	Title string `json:"title"`
+}
+
+type ambiguous struct {
+	Base
+	Other
+}
+
+type taggedWins struct {
+	Base
+	Tagged
+}
+
+type shallowWins struct {
+	Base
+	Name string
+}
+
+type quoted struct {
+	N   int     `json:"n,string"`
+	F   float64 `json:"f,string"`
+	B   bool    `json:"b,string"`
+	S   string  `json:"s,string"`
+	P   *int    `json:"p,string"`
+	Raw []int   `json:"raw,string"` // 非基础类型，string 选项无效
+}
+
+func TestDecode(t *testing.T) {
+	n := 7
+
+	tests := []struct {
+		name  string
+		input interface{}
+		want  interface{}
+	}{
+		{"float to int", float64(42), int(42)},
+		{"string to int", " 42 ", int(42)},
+		{"float to uint8", float64(255), uint8(255)},
+		{"int to float", int64(3), float64(3)},
+		{"number to string", float64(1.5), "1.5"},
+		{"string to bool", "true", true},
+		{"base64 to bytes", "aGVsbG8=", []byte("hello")},
+		{"array to bytes", []interface{}{float64(1), float64(2)}, []byte{1, 2}},
+		{"slice", []interface{}{float64(1), "2"}, []int{1, 2}},
+		{"array keeps zero", []interface{}{float64(1)}, [2]int{1, 0}},
+		{"map", map[string]interface{}{"a": float64(1)}, map[string]int{"a": 1}},
+		{"map text key", map[string]interface{}{"high": "x"}, map[level]string{2: "x"}},
+		{"pointer", float64(7), &n},
+		{"nil", nil, (*int)(nil)},
+		{"json unmarshaler", "21.5°C", celsius(21.5)},
+		{"text unmarshaler", "low", level(1)},
+		{"time", "2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{
+			"case insensitive field",
+			map[string]interface{}{"id": float64(1), "NAME": "a"},
+			Base{ID: 1, Name: "a"},
+		},
+		{
+			"exact field name first",
+			map[string]interface{}{"name": "lower", "Name": "exact"},
+			Base{Name: "exact"},
+		},
+		{
+			"case insensitive keys in stable order",
+			map[string]interface{}{"NAME": "upper", "name": "lower"},
+			Base{Name: "upper"},
+		},
+		{
+			"embedded fields",
+			map[string]interface{}{"ID": float64(1), "Name": "a", "title": "t"},
+			embedded{Base: Base{ID: 1, Name: "a"}, Title: "t"},
+		},
+		{
+			"ambiguous fields are dropped",
+			map[string]interface{}{"ID": float64(1), "Name": "a"},
+			ambiguous{Base: Base{ID: 1}},
+		},
+		{
+			"tagged field wins",
+			map[string]interface{}{"Name": "a"},
+			taggedWins{Tagged: Tagged{Name: "a"}},
+		},
+		{
+			"shallow field wins",
+			map[string]interface{}{"Name": "a"},
+			shallowWins{Name: "a"},
+		},
+		{
+			"quoted fields",
+			map[string]interface{}{"n": "12", "f": "1.5", "b": "true", "s": `"x"`, "p": "7", "raw": []interface{}{float64(1)}},
+			quoted{N: 12, F: 1.5, B: true, S: "x", P: &n, Raw: []int{1}},
+		},
+		{
+			"quoted null",
+			map[string]interface{}{"n": "null", "p": nil},
+			quoted{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(reflect.TypeOf(tt.want), tt.input)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Interface(), tt.want) {
+				t.Fatalf("Decode() = %#v, want %#v", got.Interface(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	type item struct {
+		Price uint8 `json:"price"`
+	}
+	type order struct {
+		Items []item `json:"items"`
+	}
+
+	tests := []struct {
+		name  string
+		typ   reflect.Type
+		input interface{}
+		path  string
+		err   error
+	}{
+		{"int overflow", reflect.TypeOf(int8(0)), float64(128), "", errOverflow},
+		{"float exceeds int64", reflect.TypeOf(int64(0)), float64(1 << 63), "", errOverflow},
+		{"fraction to int", reflect.TypeOf(0), float64(1.5), "", errFraction},
+		{"fraction to uint", reflect.TypeOf(uint(0)), float64(0.5), "", errFraction},
+		{"negative to uint", reflect.TypeOf(uint(0)), float64(-1), "", errOverflow},
+		{"negative int to uint", reflect.TypeOf(uint(0)), int(-1), "", errOverflow},
+		{"float32 overflow", reflect.TypeOf(float32(0)), float64(1e300), "", errOverflow},
+		{
+			"nested path",
+			reflect.TypeOf([]order{}),
+			[]interface{}{map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"price": float64(1)},
+				map[string]interface{}{"price": float64(256)},
+			}}},
+			"[0].items[1].price",
+			errOverflow,
+		},
+		{"quoted not a string", reflect.TypeOf(quoted{}), map[string]interface{}{"n": float64(1)}, "n", errQuoted},
+		{"quoted wrong kind", reflect.TypeOf(quoted{}), map[string]interface{}{"n": `"1"`}, "n", errQuoted},
+		{"quoted trailing data", reflect.TypeOf(quoted{}), map[string]interface{}{"n": "1 2"}, "n", errQuoted},
+		{"quoted unquoted string", reflect.TypeOf(quoted{}), map[string]interface{}{"s": "x"}, "s", errQuoted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.typ, tt.input)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Decode() error = %v, want *DecodeError", err)
+			}
+			if decodeErr.Path != tt.path {
+				t.Fatalf("Path = %q, want %q", decodeErr.Path, tt.path)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeUnmarshalerError(t *testing.T) {
+	_, err := Decode(reflect.TypeOf(level(0)), "medium")
+	if err == nil || !strings.Contains(err.Error(), `unknown level "medium"`) {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	// TextUnmarshaler 仅接受字符串
+	if _, err := Decode(reflect.TypeOf(level(0)), float64(1)); err != nil {
+		t.Fatalf("Decode() error = %v, want fallback to int", err)
+	}
+
+	if _, err := Decode(reflect.TypeOf([]byte{}), "not base64!"); err == nil {
+		t.Fatal("Decode() of invalid base64 succeeded")
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	var b Base
+	if err := DecodeInto(map[string]interface{}{"name": "a"}, &b); err != nil || b.Name != "a" {
+		t.Fatalf("DecodeInto() = %+v, %v", b, err)
+	}
+
+	if err := DecodeInto(nil, b); err == nil {
+		t.Fatal("DecodeInto() with non-pointer succeeded")
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	type dup struct{ Base }
+	type twice struct {
+		dup
+		Other `json:"other"`
+		Base
+	}
+
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want []string
+	}{
+		{"embedded", reflect.TypeOf(embedded{}), []string{"ID", "Name", "title"}},
+		{"ambiguous", reflect.TypeOf(ambiguous{}), []string{"ID"}},
+		{"tagged wins", reflect.TypeOf(taggedWins{}), []string{"ID", "Name"}},
+		{"shallow wins", reflect.TypeOf(shallowWins{}), []string{"ID", "Name"}},
+		{"tagged embedded is a field", reflect.TypeOf(twice{}), []string{"other", "ID", "Name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, f := range JSONFields(tt.typ) {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Fatalf("JSONFields() = %v, want %v", names, tt.want)
+			}
+
+			// 与 encoding/json 的结果一致
+			data, err := json.Marshal(reflect.New(tt.typ).Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m map[string]interface{}
+			_ = json.Unmarshal(data, &m)
+			if len(m) != len(tt.want) {
+				t.Fatalf("encoding/json fields = %v, want %v", m, tt.want)
+			}
+		})
+	}
+
+	f := JSONFields(reflect.TypeOf(quoted{}))
+	for _, field := range f {
+		if field.Name == "raw" && field.Quoted {
+			t.Fatal("string option applied to a slice field")
+		}
+		if field.Name == "p" && !field.Quoted {
+			t.Fatal("string option not applied to a pointer to int")
+		}
+	}
+}
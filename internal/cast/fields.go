@@ -0,0 +1,271 @@
+package cast
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 结构体字段按 json tag 解析后的信息
+type Field struct {
+	Name      string       // json 名称
+	Index     []int        // reflect 字段索引，嵌入结构体时为多级
+	Type      reflect.Type // 字段类型
+	OmitEmpty bool         // 是否带 omitempty
+	Quoted    bool         // 是否带 string 选项
+}
+
+var fieldsCache sync.Map // reflect.Type -> []Field
+
+// 获取结构体的 json 字段，规则与 encoding/json 一致：
+//   - 仅导出字段，`json:"-"` 忽略
+//   - 未设置 tag 名称的嵌入结构体（或其指针）字段会被展开
+//   - 同名字段层级浅的优先；同一层级仅有一个带 tag 名称的字段时其优先，否则同名字段全部忽略
+//   - string 选项仅对布尔、数字、字符串（或其指针）字段生效
+func JSONFields(t reflect.Type) []Field {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.([]Field)
+	}
+
+	fields := collectFields(t)
+
+	// 同名的字段相邻，按层级、是否带 tag 名称、声明顺序排列
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.field.Name != b.field.Name {
+			return a.field.Name < b.field.Name
+		}
+		if len(a.field.Index) != len(b.field.Index) {
+			return len(a.field.Index) < len(b.field.Index)
+		}
+		if a.tagged != b.tagged {
+			return a.tagged
+		}
+		return lessIndex(a.field.Index, b.field.Index)
+	})
+
+	var result []Field
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].field.Name == fields[i].field.Name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			result = append(result, f)
+		}
+		i = j
+	}
+
+	// 与 encoding/json 一致，按字段声明顺序排列
+	sort.Slice(result, func(i, j int) bool {
+		return lessIndex(result[i].Index, result[j].Index)
+	})
+
+	fieldsCache.Store(t, result)
+	return result
+}
+
+// 同名字段中起作用的字段，fields 已按层级、是否带 tag 名称排序；存在歧义时返回 false
+func dominantField(fields []collected) (Field, bool) {
+	if len(fields) > 1 && len(fields[0].field.Index) == len(fields[1].field.Index) && fields[0].tagged == fields[1].tagged {
+		return Field{}, false
+	}
+	return fields[0].field, true
+}
+
+func lessIndex(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+type collected struct {
+	field  Field
+	tagged bool // 是否设置了 tag 名称
+}
+
+// 按层级逐层展开嵌入结构体，同一结构体只在最浅的层级展开
+func collectFields(t reflect.Type) []collected {
+	type embed struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []collected
+
+	next := []embed{{typ: t}}
+	count := map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		currentCount := count
+		count = map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					// 未导出的非结构体嵌入字段忽略，未导出的嵌入结构体仍会展开其导出字段
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, opts, _ := strings.Cut(tag, ",")
+
+				index := append(append([]int{}, e.index...), i)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// 未设置名称的嵌入结构体，在下一层级展开
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					count[ft]++
+					if count[ft] == 1 {
+						next = append(next, embed{typ: ft, index: index})
+					}
+					continue
+				}
+
+				f := collected{
+					field: Field{
+						Name:      name,
+						Index:     index,
+						Type:      sf.Type,
+						OmitEmpty: hasOption(opts, "omitempty"),
+						Quoted:    hasOption(opts, "string") && isQuotable(ft.Kind()),
+					},
+					tagged: name != "",
+				}
+				if name == "" {
+					f.field.Name = sf.Name
+				}
+
+				fields = append(fields, f)
+
+				// 同一层级多次嵌入同一结构体，其字段重复出现，会因歧义被忽略
+				if currentCount[e.typ] > 1 {
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+func isQuotable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// 带 string 选项的字段值，与 encoding/json 一致，按 JSON 编码后作为字符串，如 `"1"`、`"\"abc\""`
+//
+// 值为 nil 指针时返回 false，按普通字段处理
+func QuoteValue(v reflect.Value) (string, bool, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// 按字段索引获取值，经过的嵌入指针为 nil 时返回 false
+func FieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// 按字段索引获取可写的值，经过的嵌入指针为 nil 时自动创建，无法创建或字段不可写时返回 false
+func FieldValueAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
+
+// 与 encoding/json 的 omitempty 判断一致
+func IsEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
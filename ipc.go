@@ -28,91 +28,151 @@ type Callback interface{}
 
 type resultCallback func(result interface{}, err error)
 
-// resultCallback 用于区分无须返回值的情况
-type ipcHandler func(cb resultCallback, args ...interface{})
+// resultCallback 用于区分无须返回值的情况，ctx 取消时应尽快通过 cb 返回错误
+type ipcHandler func(ctx context.Context, cb resultCallback, args ...interface{})
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// 保证 callback 只会被调用一次，cb 为空时忽略结果
+func onceCallback(cb resultCallback) resultCallback {
+	var once sync.Once
+	return func(result interface{}, err error) {
+		once.Do(func() {
+			if cb != nil {
+				cb(result, err)
+			}
+		})
+	}
+}
+
+func ipcContextError(channel string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("ipc channel %s 调用超时: %w", channel, err)
+	}
+	return fmt.Errorf("ipc channel %s 调用已取消: %w", channel, err)
+}
+
+// 剩余的超时时间（毫秒），没有截止时间则返回 0
+func ctxTimeoutMs(ctx context.Context) int64 {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	ms := time.Until(deadline).Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	return ms
+}
 
-// 封装 ipcPedding, 为 Get/Add/Del 提供锁保护
+type ipcPenddingCall struct {
+	cb   resultCallback
+	done chan struct{}
+}
+
+// 封装 ipcPedding, 为 Add/Take 提供锁保护
 type ipcPendding struct {
-	ctx       context.Context
-	mu        *sync.Mutex
-	callbacks map[string]resultCallback
+	mu    sync.Mutex
+	calls map[string]*ipcPenddingCall
 }
 
-func newIPCPendding(ctx context.Context) *ipcPendding {
+func newIPCPendding() *ipcPendding {
 	return &ipcPendding{
-		ctx:       ctx,
-		mu:        &sync.Mutex{},
-		callbacks: make(map[string]resultCallback),
+		calls: make(map[string]*ipcPenddingCall),
 	}
 }
 
-func (p *ipcPendding) Add(id string, cb resultCallback) {
-	// 异步，避免阻塞
-	utils.Go(func() {
-		p.mu.Lock()
-		defer p.mu.Unlock()
-		p.callbacks[id] = cb
-	}, nil)
+// 添加等待结果的 callback，ctx 取消时以 ctx 的错误回调，并执行 onCancel
+func (p *ipcPendding) Add(ctx context.Context, id string, cb resultCallback, onCancel func()) {
+	call := &ipcPenddingCall{
+		cb:   cb,
+		done: make(chan struct{}),
+	}
 
-	// 超时处理
-	utils.Go(func() {
+	p.mu.Lock()
+	p.calls[id] = call
+	p.mu.Unlock()
 
-		time.Sleep(10 * time.Second)
+	// 超时、取消处理
+	utils.Go(func() {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			cb, exist := p.Take(id)
+			if !exist {
+				return
+			}
 
-		if p.mu.TryLock() {
-			defer p.mu.Unlock()
-		}
+			cb(nil, ctx.Err())
 
-		cb, exist := p.callbacks[id]
-		if !exist {
-			return
+			if onCancel != nil {
+				onCancel()
+			}
 		}
-
-		delete(p.callbacks, id) // 删除等待结果的callback
-
-		cb(nil, errors.New("等待 JS Handler 处理结果超时"))
 	}, nil)
 }
 
-func (p *ipcPendding) Del(id string) {
-	// 异步，避免阻塞
-	utils.Go(func() {
-		p.mu.Lock()
-		defer p.mu.Unlock()
-		delete(p.callbacks, id)
-	}, nil)
-}
+// 取出并删除等待结果的 callback
+func (p *ipcPendding) Take(id string) (resultCallback, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	call, exist := p.calls[id]
+	if !exist {
+		return nil, false
+	}
+
+	delete(p.calls, id)
+	close(call.done)
 
-func (p *ipcPendding) Get(id string) (resultCallback, bool) {
-	cb, exist := p.callbacks[id]
-	return cb, exist
+	return call.cb, true
 }
 
 type IPC struct {
 	mb *Blink
 
-	handlers map[string]ipcHandler
+	// 默认超时时间，用于 Invoke 以及 JS 端的 ipc.invoke，为 0 时不限制
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]ipcHandler   // GO 注册的全局 handler
+	types    map[string]reflect.Type // 全局 handler 的函数类型，用于生成 TypeScript 声明
+
+	// 注册了 JS handler 的 view，按注册顺序排列
+	jsChannels map[string][]*View
+
+	running sync.Map // 正在执行的 JS 调用，id -> context.CancelFunc
+	streams sync.Map // 正在输出的流，id -> *StreamWriter
 
 	pendding *ipcPendding
 }
 
 type IPCMessage struct {
-	ID      string        `json:"id"`               // 消息 ID
-	ReplyId string        `json:"replyId"`          // 回复ID
-	Channel string        `json:"channel"`          // 通道
-	Args    []interface{} `json:"args"`             // 参数
-	Result  interface{}   `json:"result,omitempty"` // 返回值，当有回复ID时，此字段有效
-	Error   string        `json:"error,omitempty"`  // 是否错误，当有回复ID时，此字段有效
+	ID       string        `json:"id"`                 // 消息 ID
+	ReplyId  string        `json:"replyId"`            // 回复ID
+	Channel  string        `json:"channel"`            // 通道
+	Args     []interface{} `json:"args"`               // 参数
+	Result   interface{}   `json:"result,omitempty"`   // 返回值，当有回复ID时，此字段有效
+	Error    string        `json:"error,omitempty"`    // 是否错误，当有回复ID时，此字段有效
+	Timeout  int64         `json:"timeout,omitempty"`  // 超时时间（毫秒），为 0 时不限制
+	CancelId string        `json:"cancelId,omitempty"` // 取消的消息ID，对方收到后不再回复
+	Stream   bool          `json:"stream,omitempty"`   // 是否为流式调用
+	Credit   int           `json:"credit,omitempty"`   // 流式调用时，接收方可以继续接收的数量
+	Done     bool          `json:"done,omitempty"`     // 流式调用时，是否已结束
 }
 
 func newIPC(mb *Blink) *IPC {
 	ipc := &IPC{
 		mb: mb,
 
-		handlers: make(map[string]ipcHandler),
+		timeout: mb.GetIPCTimeout(),
+
+		handlers:   make(map[string]ipcHandler),
+		types:      make(map[string]reflect.Type),
+		jsChannels: make(map[string][]*View),
 	}
 
-	ipc.pendding = newIPCPendding(mb.Ctx)
+	ipc.pendding = newIPCPendding()
 
 	ipc.registerBootScript()
 	ipc.registerJS2GO()
@@ -121,50 +181,164 @@ func newIPC(mb *Blink) *IPC {
 	return ipc
 }
 
-// GO 调用handler
+// GO 调用handler，使用默认超时时间
 //
 //	一、GO 调用 GO handler，直接调用并返回
 //
 //	二、GO 调用 JS handler, 和 GO 调用 GO 流程一样，唯一区别是在 `invokeJS` 里调用 `ipc.Invoke` 执行的 `handler` 是转化后的 `JS handler`
+//
+// 多个 view 注册了同一个 JS handler 时，仅调用最后注册的 view，需要指定 view 请使用 `View.IPC()`
 func (ipc *IPC) Invoke(channel string, args ...interface{}) (interface{}, error) {
-	handler, exist := ipc.handlers[channel]
+	ctx, cancel := ipc.withTimeout(ipc.mb.Ctx)
+	defer cancel()
+
+	return ipc.InvokeContext(ctx, channel, args...)
+}
+
+// GO 调用handler，ctx 取消或超时后立即返回错误。
+//
+// 调用 JS handler 时，ctx 的截止时间会传递给 JS 端，取消时通知 JS 端不再回复
+func (ipc *IPC) InvokeContext(ctx context.Context, channel string, args ...interface{}) (interface{}, error) {
+	handler, exist := ipc.lookup(channel)
+	return invokeHandler(ctx, channel, handler, exist, args...)
+}
+
+func (ipc *IPC) Sent(channel string, args ...interface{}) error {
+	handler, exist := ipc.lookup(channel)
+	return sentHandler(ipc.mb.Ctx, channel, handler, exist, args...)
+}
+
+// 向所有注册了 channel 的 view 发送消息，无须返回值
+//
+// 某个 view 发送失败时继续发送其余 view，返回所有失败的错误
+func (ipc *IPC) Broadcast(channel string, args ...interface{}) error {
+	ipc.mu.RLock()
+	views := append([]*View{}, ipc.jsChannels[channel]...)
+	ipc.mu.RUnlock()
+
+	var errs []error
+	for _, view := range views {
+		if err := view.IPC().Sent(channel, args...); err != nil {
+			errs = append(errs, fmt.Errorf("view %d: %w", view.Hwnd, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// 查找全局 handler，优先 GO handler，其次是最后注册该 channel 的 view 的 JS handler
+func (ipc *IPC) lookup(channel string) (ipcHandler, bool) {
+	ipc.mu.RLock()
+	defer ipc.mu.RUnlock()
+
+	if handler, exist := ipc.handlers[channel]; exist {
+		return handler, true
+	}
+
+	views := ipc.jsChannels[channel]
+	if len(views) == 0 {
+		return nil, false
+	}
+
+	handler, exist := views[len(views)-1].ipc.jsHandlers[channel]
+	return handler, exist
+}
+
+func invokeHandler(ctx context.Context, channel string, handler ipcHandler, exist bool, args ...interface{}) (interface{}, error) {
 	if !exist {
 		msg := fmt.Sprintf("ipc channel %s not exist", channel)
 		log.Error(msg)
 		return nil, errors.New(msg)
 	}
 
-	result := make(chan interface{}, 1)
-	err := make(chan error, 1)
+	if err := ctx.Err(); err != nil {
+		return nil, ipcContextError(channel, err)
+	}
 
-	// 将 callback 转 chan
-	handler(func(res interface{}, e error) {
-		result <- res
-		err <- e
-	}, args...)
+	type reply struct {
+		result interface{}
+		err    error
+	}
+
+	ch := make(chan reply, 1)
 
-	return <-result, <-err
+	// 将 callback 转 chan
+	handler(ctx, onceCallback(func(res interface{}, e error) {
+		ch <- reply{res, e}
+	}), args...)
+
+	select {
+	case r := <-ch:
+		return r.result, r.err
+	case <-ctx.Done():
+		return nil, ipcContextError(channel, ctx.Err())
+	}
 }
 
-func (ipc *IPC) Sent(channel string, args ...interface{}) error {
-	handler, exist := ipc.handlers[channel]
+func sentHandler(ctx context.Context, channel string, handler ipcHandler, exist bool, args ...interface{}) error {
 	if !exist {
 		msg := fmt.Sprintf("ipc channel %s not exist", channel)
 		log.Error(msg)
 		return errors.New(msg)
 	}
 
-	handler(nil, args...)
+	handler(ctx, nil, args...)
 
 	return nil
 }
 
+// 可以调用 handler 的对象，IPC 和 ViewIPC 均实现了此接口
+type Invoker interface {
+	InvokeContext(ctx context.Context, channel string, args ...interface{}) (interface{}, error)
+}
+
+// 调用 handler，并将结果转换为 T 类型
+func InvokeAs[T any](ctx context.Context, invoker Invoker, channel string, args ...interface{}) (result T, err error) {
+	res, err := invoker.InvokeContext(ctx, channel, args...)
+	if err != nil {
+		return
+	}
+
+	err = decodeResult(res, &result)
+	return
+}
+
+// 将 handler 的返回值解码到 out 指向的变量
+func decodeResult(res interface{}, out interface{}) error {
+	if res == nil {
+		return nil
+	}
+
+	return cast.DecodeInto(res, out)
+}
+
+// 基于默认超时时间创建 context
+func (ipc *IPC) withTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	if ipc.timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, ipc.timeout)
+}
+
 // GO 注册 Handler
 //
 // handler 必须为函数，参数任意，返回值最多为2个
+//   - 开头的参数可以为 context.Context 和 *View（调用方所在的 view，GO 端调用时为 nil），不占用调用参数
+//   - 流式 handler：返回 <-chan T，或开头参数含 *StreamWriter，JS 端使用 ipc.stream 逐个接收；非流式调用时结果汇总为数组
 //   - 1个返回值：会自动判断返回值是否为 error
 //   - 2个返回值：第一个为 结果，第二个为 error
 func (ipc *IPC) Handle(channel string, handler Callback) {
+	h := newGoHandler(channel, handler)
+
+	ipc.mu.Lock()
+	defer ipc.mu.Unlock()
+
+	ipc.handlers[channel] = h
+	ipc.types[channel] = reflect.TypeOf(handler)
+}
+
+// 将 GO 函数转为 ipcHandler
+func newGoHandler(channel string, handler Callback) ipcHandler {
 
 	// 使用反射获取处理函数的类型
 	handlerVal := reflect.ValueOf(handler)
@@ -174,7 +348,29 @@ func (ipc *IPC) Handle(channel string, handler Callback) {
 
 	handlerType := handlerVal.Type()
 
-	ipc.handlers[channel] = func(cb resultCallback, inputs ...interface{}) {
+	// 开头为 context.Context、*View 或 *StreamWriter 的参数，不占用调用参数
+	offset := 0
+	hasWriter := false
+	for offset < handlerType.NumIn() && offset < 3 {
+		in := handlerType.In(offset)
+		if in != contextType && in != viewType && in != streamWriterType {
+			break
+		}
+		if in == streamWriterType {
+			hasWriter = true
+		}
+		offset++
+	}
+
+	return func(ctx context.Context, cb resultCallback, inputs ...interface{}) {
+
+		reply := onceCallback(cb)
+
+		// 流式调用时使用调用方的 writer，否则汇总写入的数据作为结果
+		writer, streaming := streamFromContext(ctx)
+		if !streaming {
+			writer = newCollectWriter(ctx)
+		}
 
 		inputSize := len(inputs)
 
@@ -184,36 +380,52 @@ func (ipc *IPC) Handle(channel string, handler Callback) {
 		if isVariadic {
 			pCount = pCount - 1
 		}
-		inVals := make([]reflect.Value, pCount)
-		for i := 0; i < pCount; i++ {
+		inVals := make([]reflect.Value, 0, pCount)
+		for i := 0; i < offset; i++ {
+			switch handlerType.In(i) {
+			case contextType:
+				inVals = append(inVals, reflect.ValueOf(&ctx).Elem())
+			case streamWriterType:
+				inVals = append(inVals, reflect.ValueOf(writer))
+			default:
+				view, _ := ViewFromContext(ctx)
+				inVals = append(inVals, reflect.ValueOf(view))
+			}
+		}
+		for i := offset; i < pCount; i++ {
 
 			param := handlerType.In(i)
 
 			var inputVal reflect.Value
 			var err error
 
-			if i < inputSize {
-				inputVal, err = cast.Param(param, inputs[i])
+			idx := i - offset
+			if idx < inputSize {
+				inputVal, err = cast.Param(param, inputs[idx])
 				if err != nil {
-					cb(nil, err)
+					reply(nil, fmt.Errorf("ipc channel %s 参数[%d]: %w", channel, idx, err))
 					return
 				}
 			} else {
 				inputVal = reflect.Zero(param)
 			}
 
-			inVals[i] = inputVal
+			inVals = append(inVals, inputVal)
 		}
 
 		if isVariadic {
 			// 处理可变参数
-			inputs = inputs[pCount:]
-			inputSize := len(inputs)
+			if start := pCount - offset; start < inputSize {
+				inputs = inputs[start:]
+			} else {
+				inputs = nil
+			}
 			elem := handlerType.In(handlerType.NumIn() - 1).Elem()
-			for i := 0; i < inputSize; i++ {
+			for i := 0; i < len(inputs); i++ {
 				inputVal, err := cast.Param(elem, inputs[i])
 				if err != nil {
-					cb(nil, err)
+					err = fmt.Errorf("ipc channel %s 参数[%d]: %w", channel, pCount-offset+i, err)
+					reply(nil, err)
 					log.Error(err.Error())
 					return
 				}
@@ -221,62 +433,73 @@ func (ipc *IPC) Handle(channel string, handler Callback) {
 			}
 		}
 
+		done := make(chan struct{})
+
 		// 异步处理 handler
 		utils.Go(func() {
+			defer close(done)
 
-			select {
-			case <-time.After(10 * time.Second):
-				log.Debug("IPC 调用超时")
-				cb(nil, errors.New("IPC 调用超时"))
-				return
-			default:
-				// 调用处理函数
-				out := handlerVal.Call(inVals)
-
-				if cb == nil {
-					return
+			finish := func(res interface{}, err error) {
+				if err == nil {
+					res, err = writer.resolve(res, hasWriter)
 				}
+				reply(res, err)
+			}
 
-				// 处理返回值
-				if len(out) == 0 {
-					// 没有返回值
-					cb(nil, nil)
-				} else if len(out) == 1 {
-					// 只有一个返回值
-					result := out[0].Interface()
-
-					switch res := result.(type) {
-					case error:
-						cb(nil, res)
-					default:
-						cb(res, nil)
-					}
-				} else if len(out) == 2 {
-					// 有2个返回值
-					res := out[0].Interface()
-					var err error
-					switch e := out[1].Interface().(type) {
-					case error:
-						err = e
-					default:
-						err = nil
-					}
-					cb(res, err)
-				} else {
-					// 多个返回值
-					cb(nil, fmt.Errorf("more than 2 return values are not supported"))
+			// 调用处理函数
+			out := handlerVal.Call(inVals)
+
+			// 处理返回值
+			if len(out) == 0 {
+				// 没有返回值
+				finish(nil, nil)
+			} else if len(out) == 1 {
+				// 只有一个返回值
+				result := out[0].Interface()
+
+				switch res := result.(type) {
+				case error:
+					finish(nil, res)
+				default:
+					finish(res, nil)
 				}
+			} else if len(out) == 2 {
+				// 有2个返回值
+				res := out[0].Interface()
+				var err error
+				switch e := out[1].Interface().(type) {
+				case error:
+					err = e
+				default:
+					err = nil
+				}
+				finish(res, err)
+			} else {
+				// 多个返回值
+				finish(nil, fmt.Errorf("more than 2 return values are not supported"))
 			}
 
 		}, func(err error) {
 			log.Error("panic by ipc handler[ %v ]: %v", channel, err)
-			cb(nil, err)
+			reply(nil, err)
 		})
+
+		// 超时、取消处理
+		if cb != nil && ctx.Done() != nil {
+			utils.Go(func() {
+				select {
+				case <-done:
+				case <-ctx.Done():
+					log.Debug("IPC 调用超时或取消: %s", channel)
+					reply(nil, ipcContextError(channel, ctx.Err()))
+				}
+			}, nil)
+		}
 	}
 }
 
 func (ipc *IPC) HasChannel(channel string) (exist bool) {
-	_, exist = ipc.handlers[channel]
+	_, exist = ipc.lookup(channel)
 	return
 }
 
@@ -291,6 +514,8 @@ func (ipc *IPC) registerBootScript() {
 		JS_JS2GO,
 		JS_GO2JS,
 		JS_REGISTER_HANDLER,
+		JS_BINARY_KEY,
+		ipc.timeout.Milliseconds(),
 	)
 
 	ipc.mb.AddBootScript(script)
@@ -310,6 +535,21 @@ func (ipc *IPC) registerJS2GO() {
 			return
 		}
 
+		// 第一个参数之后为二进制数据
+		if count := ipc.mb.js.ArgCount(es); count > 1 {
+			buffers := make([][]byte, 0, count-1)
+			for i := uint32(1); i < count; i++ {
+				buffers = append(buffers, ipc.mb.js.GetArrayBuffer(es, ipc.mb.js.Arg(es, i)))
+			}
+			restoreMsgBinary(&msg, buffers)
+		}
+
+		// 流式调用的接收额度
+		if msg.Stream && msg.ReplyId != "" {
+			ipc.addStreamCredit(msg.ReplyId, msg.Credit)
+			return
+		}
+
 		if msg.ReplyId != "" {
 			ipc.mb.AddJob(func() {
 				ipc.handleJSReply(&msg)
@@ -317,11 +557,20 @@ func (ipc *IPC) registerJS2GO() {
 			return
 		}
 
+		// JS 端取消调用
+		if msg.CancelId != "" {
+			ipc.cancelRunning(msg.CancelId)
+			return
+		}
+
 		if msg.Channel != "" {
 			if view, exist := ipc.mb.GetViewByJsExecState(es); exist {
 
-				ipc.mb.AddJob(func() {
+				// 使用协程，避免 handler 耗时过长阻塞 UI 线程
+				utils.Go(func() {
 					ipc.invokeByJS(view, &msg)
+				}, func(err error) {
+					log.Error("panic by ipc invoke[ %v ]: %v", msg.Channel, err)
 				})
 			}
 			return
@@ -332,14 +581,36 @@ func (ipc *IPC) registerJS2GO() {
 // JS 调用 handler
 func (ipc *IPC) invokeByJS(view *View, msg *IPCMessage) {
 
+	handler, exist := view.ipc.lookupForJS(msg.Channel)
+
+	ctx := withView(ipc.mb.Ctx, view)
+
 	// 如果 ID 为空，则无须回复返回值
 	if msg.ID == "" {
-		_ = ipc.Sent(msg.Channel, msg.Args...)
+		_ = sentHandler(ctx, msg.Channel, handler, exist, msg.Args...)
+		return
+	}
+
+	// 使用 JS 端传入的超时时间
+	var cancel context.CancelFunc
+	if msg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(msg.Timeout)*time.Millisecond)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	// 记录正在执行的调用，以便 JS 端取消
+	ipc.running.Store(msg.ID, cancel)
+	defer ipc.running.Delete(msg.ID)
+
+	if msg.Stream {
+		ipc.invokeStream(ctx, view, msg, handler, exist)
 		return
 	}
 
 	// 调用 invoke 获取到结果
-	result, err := ipc.Invoke(msg.Channel, msg.Args...)
+	result, err := invokeHandler(ctx, msg.Channel, handler, exist, msg.Args...)
 
 	e := ""
 	if err != nil {
@@ -354,7 +625,10 @@ func (ipc *IPC) invokeByJS(view *View, msg *IPCMessage) {
 		Result:  result,
 	}
 
-	sentMsgToView(view, replyMsg)
+	// 结果无法发送（如含有循环引用）时，以错误回复
+	if err := sentMsgToView(view, replyMsg); err != nil {
+		_ = sentMsgToView(view, IPCMessage{ReplyId: msg.ID, Error: err.Error()})
+	}
 }
 
 func (ipc *IPC) handleJSReply(msg *IPCMessage) {
@@ -362,13 +636,11 @@ func (ipc *IPC) handleJSReply(msg *IPCMessage) {
 		return
 	}
 
-	cb, exist := ipc.pendding.Get(msg.ReplyId)
+	cb, exist := ipc.pendding.Take(msg.ReplyId) // 接收到消息就从 map 中删除
 	if !exist {
 		return
 	}
 
-	ipc.pendding.Del(msg.ReplyId) // 接收到消息就从 map 中删除
-
 	if msg.Error != "" {
 		cb(nil, errors.New(msg.Error))
 	} else {
@@ -389,32 +661,83 @@ func (ipc *IPC) registerJSHandler() {
 			return
 		}
 
-		// 将 JS handler 转为 GO handler
-		ipc.handlers[channel] = func(cb resultCallback, args ...interface{}) {
+		view.ipc.registerJSHandler(channel, ipc.newJSHandler(view, channel))
 
-			if cb == nil {
-				msg := IPCMessage{
-					ID:      "", // ID 为空则不需要回复
-					Channel: channel,
-					Args:    args,
-				}
-				sentMsgToView(view, msg)
-				return
-			}
+		ipc.mu.Lock()
+		defer ipc.mu.Unlock()
+
+		// 重复注册时移到最后
+		views := removeView(ipc.jsChannels[channel], view)
+		ipc.jsChannels[channel] = append(views, view)
+	})
+}
 
-			id := utils.RandString(8) // 生成key
+// 将 JS handler 转为 GO handler
+func (ipc *IPC) newJSHandler(view *View, channel string) ipcHandler {
+	return func(ctx context.Context, cb resultCallback, args ...interface{}) {
 
+		if cb == nil {
 			msg := IPCMessage{
-				ID:      id,
+				ID:      "", // ID 为空则不需要回复
 				Channel: channel,
 				Args:    args,
 			}
+			_ = sentMsgToView(view, msg)
+			return
+		}
 
-			ipc.pendding.Add(id, cb) // 添加到等待结果的 map
+		id := utils.RandString(8) // 生成key
 
-			sentMsgToView(view, msg)
+		msg := IPCMessage{
+			ID:      id,
+			Channel: channel,
+			Args:    args,
+			Timeout: ctxTimeoutMs(ctx),
 		}
-	})
+
+		// 添加到等待结果的 map，取消时通知 JS 端不再回复
+		ipc.pendding.Add(ctx, id, cb, func() {
+			_ = sentMsgToView(view, IPCMessage{CancelId: id})
+		})
+
+		ipc.sentPendding(view, id, msg)
+	}
+}
+
+// 发送需要回复的消息，发送失败时直接以错误结束等待
+func (ipc *IPC) sentPendding(view *View, id string, msg IPCMessage) {
+	if err := sentMsgToView(view, msg); err != nil {
+		if cb, exist := ipc.pendding.Take(id); exist {
+			cb(nil, err)
+		}
+	}
+}
+
+// view 销毁或页面的 script context 释放后，移除其注册的 JS handler
+func (ipc *IPC) removeView(view *View) {
+	ipc.mu.Lock()
+	defer ipc.mu.Unlock()
+
+	view.ipc.jsHandlers = make(map[string]ipcHandler)
+
+	for channel, views := range ipc.jsChannels {
+		views = removeView(views, view)
+		if len(views) == 0 {
+			delete(ipc.jsChannels, channel)
+		} else {
+			ipc.jsChannels[channel] = views
+		}
+	}
+}
+
+func removeView(views []*View, view *View) []*View {
+	result := views[:0]
+	for _, v := range views {
+		if v != view {
+			result = append(result, v)
+		}
+	}
+	return result
 }
 
 func (ipc *IPC) RunJsFunc(view *View, funcName string, args ...interface{}) *promise.Promise[any] {
@@ -425,10 +748,13 @@ func (ipc *IPC) RunJsFunc(view *View, funcName string, args ...interface{}) *pro
 
 	id := utils.RandString(8) // 生成key
 
+	ctx, cancel := ipc.withTimeout(ipc.mb.Ctx)
+
 	msg := IPCMessage{
 		ID:      id,
 		Channel: "callJsFunc",
 		Args:    newArgs,
+		Timeout: ctxTimeoutMs(ctx),
 	}
 
 	resolve := func(any) {}
@@ -439,6 +765,7 @@ func (ipc *IPC) RunJsFunc(view *View, funcName string, args ...interface{}) *pro
 	})
 
 	cb := func(result any, err error) {
+		cancel()
 		if err != nil {
 			reject(err)
 		} else {
@@ -446,20 +773,53 @@ func (ipc *IPC) RunJsFunc(view *View, funcName string, args ...interface{}) *pro
 		}
 	}
 
-	ipc.pendding.Add(id, cb)
+	ipc.pendding.Add(ctx, id, cb, func() {
+		_ = sentMsgToView(view, IPCMessage{CancelId: id})
+	})
 
-	sentMsgToView(view, msg)
+	ipc.sentPendding(view, id, msg)
 
 	return p
 }
 
-func sentMsgToView(view *View, msg IPCMessage) {
+// 发送消息到 view 的 JS 端，参数、结果无法序列化（如含有循环引用）时返回错误
+func sentMsgToView(view *View, msg IPCMessage) error {
 
-	msgTxt, _ := json.Marshal(msg)
+	msg, buffers, err := extractMsgBinary(msg)
+	if err != nil {
+		log.Error("GO -> JS, 消息序列化出错: %s", err.Error())
+		return err
+	}
 
-	script := fmt.Sprintf(`window.top['%s'](%q)`, JS_GO2JS, msgTxt)
+	msgTxt, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("GO -> JS, JSON 序列化出错: %s", err.Error())
+		return err
+	}
+
+	log.Debug("GO -> JS: %s, binary: %d", msgTxt, len(buffers))
+
+	// 直接调用 JS 函数传参，二进制数据作为 ArrayBuffer 附加在消息之后
+	//
+	// 创建参数、调用需要多次原生调用，在 miniblink 线程中一次执行完，而不是每次调用各排队一次
+	send := func() {
+		js := view.mb.js
+		es := js.GlobalExec(view.Hwnd)
+
+		args := make([]JsValue, 0, len(buffers)+1)
+		args = append(args, js.String(es, string(msgTxt)))
+		for _, buf := range buffers {
+			args = append(args, js.ArrayBuffer(es, buf))
+		}
 
-	log.Debug("GO -> JS: %s", msgTxt)
+		js.Call(es, js.GetGlobal(es, JS_GO2JS), js.Undefined(), args)
+	}
 
-	view.RunJs(script)
+	if view.mb.threadID == currentThreadID() {
+		send()
+	} else {
+		<-view.mb.AddJob(send)
+	}
+
+	return nil
 }
@@ -5,6 +5,9 @@
     const JS_JS2GO = '%s';
     const JS_GO2JS = '%s';
     const JS_REGISTER_HANDLER = '%s';
+    const JS_BINARY_KEY = '%s'; // 二进制数据占位符的键名
+    const DEFAULT_TIMEOUT = %d; // 默认超时时间（毫秒），为 0 时不限制
+    const STREAM_WINDOW = 16; // 流式调用的接收窗口大小
 
     // MB
 
@@ -13,12 +16,17 @@
     mb.newMsg = newMsg;
     mb.replyWaiting = mb.replyWaiting || {};
     mb.handlers = mb.handlers || {};
+    mb.running = mb.running || {}; // 正在执行、尚未回复的 handler 调用
+    mb.cancelled = mb.cancelled || {};
+    mb.streams = mb.streams || {};
 
 
     // IPC
     window.top[JS_IPC] = window.top[JS_IPC] || {}
     const ipc = window.top[JS_IPC];
     ipc.invoke = invoke;
+    ipc.invokeWithTimeout = invokeWithTimeout;
+    ipc.stream = stream;
     ipc.sent = sent;
     ipc.handle = handle;
 
@@ -34,8 +42,16 @@
     }
 
     // GO 调用 (JS预留函数)
-    window.top[JS_GO2JS] = (msgTxt) => {
-        const msg = JSON.parse(msgTxt);
+    window.top[JS_GO2JS] = (msgTxt, ...buffers) => {
+        const msg = decodeMsg(msgTxt, buffers);
+        if (msg.cancelId) {
+            if (mb.running[msg.cancelId]) mb.cancelled[msg.cancelId] = true // 仅记录尚未回复的调用
+            return
+        }
+        if (msg.stream && msg.replyId) {
+            handleStream(msg)
+            return
+        }
         if (msg.replyId) {
             handleReply(msg)
             return
@@ -45,8 +61,12 @@
         return
     };
 
-    // JS调用 (GO预埋点)
-    const toGO = (msg) => window.top[JS_JS2GO](JSON.stringify(msg))
+    // JS调用 (GO预埋点)，二进制数据作为 ArrayBuffer 附加在消息之后
+    const toGO = (msg) => {
+        const buffers = [];
+        const msgTxt = encodeMsg(msg, buffers);
+        window.top[JS_JS2GO](msgTxt, ...buffers)
+    }
     const registerHandlerToGo = window.top[JS_REGISTER_HANDLER]
 
     // 注册 callJsFunc (仅 JS 端)
@@ -67,11 +87,41 @@
         }
         return randomString;
     }
-    function newMsg({ id = '', replyId = '', channel = '', args = [], result = undefined, error = undefined }) {
-        return { id, replyId, channel, args, result, error }
+    function newMsg({ id = '', replyId = '', channel = '', args = [], result = undefined, error = undefined, timeout = 0, cancelId = '', stream = false, credit = 0 }) {
+        return { id, replyId, channel, args, result, error, timeout, cancelId, stream, credit }
+    }
+
+    // ArrayBuffer/TypedArray/DataView 替换为占位符，数据另行传递
+    function encodeMsg(msg, buffers) {
+        return JSON.stringify(msg, (key, value) => {
+            if (value instanceof ArrayBuffer) {
+                buffers.push(value);
+                return { [JS_BINARY_KEY]: buffers.length - 1 };
+            }
+            if (ArrayBuffer.isView(value)) {
+                buffers.push(value.buffer.slice(value.byteOffset, value.byteOffset + value.byteLength));
+                return { [JS_BINARY_KEY]: buffers.length - 1 };
+            }
+            return value;
+        })
     }
 
-    function withTimeout(promise, ms = 10000) {
+    // 占位符还原为 Uint8Array
+    function decodeMsg(msgTxt, buffers) {
+        if (!buffers.length) return JSON.parse(msgTxt);
+        return JSON.parse(msgTxt, (key, value) => {
+            if (value && typeof value === 'object' && !Array.isArray(value)) {
+                const keys = Object.keys(value);
+                if (keys.length === 1 && keys[0] === JS_BINARY_KEY) {
+                    return new Uint8Array(buffers[value[JS_BINARY_KEY]] || new ArrayBuffer(0));
+                }
+            }
+            return value;
+        })
+    }
+
+    function withTimeout(promise, ms = DEFAULT_TIMEOUT) {
+        if (!ms || ms <= 0) return promise;
         let timer;
         const timeout = new Promise((_, reject) => {
             timer = setTimeout(() => reject(new Error('等待IPC Handler返回处理结果超时。')), ms);
@@ -92,17 +142,36 @@
         p.resolve(msg.result)
     }
 
+    // 流式调用的数据、结束消息
+    function handleStream(msg) {
+        const s = mb.streams[msg.replyId]
+        if (!s) return;
+        if (msg.done) {
+            s.end(msg.error)
+            return;
+        }
+        s.push(msg.result)
+    }
+
     // 执行handler。（GO 调用此函数，用于执行对应的handler)
     async function handleChannel(msg) {
-        const { id, channel, args = [] } = msg || {};
+        const { id, channel, args = [], timeout = 0 } = msg || {};
         if (!channel) return;
         const handler = mb.handlers[channel];
         if (!handler) return;
-        if (!id) return; // ! 如果 ID 为空，则无须回复
+        if (!id) {
+            // ! ID 为空是 GO 端的 Sent / Broadcast，同样需要执行 handler，只是无须回复
+            // 原先在此直接返回，GO 端向 JS handler 发送的消息都会被丢弃
+            Promise.resolve().then(() => handler(...args)).catch(err => console.error(`IPC handler ${channel}:`, err));
+            return;
+        }
+        mb.running[id] = true;
         try {
-            const res = await Promise.resolve(handler(...args)); // 支持 promise
-            toGO(newMsg({ replyId: id, channel, args, result: res })) // 返回结果
+            const res = await withTimeout(Promise.resolve(handler(...args)), timeout); // 支持 promise
+            if (consumeCancelled(id)) return; // GO 端已取消，不再回复
+            toGO(newMsg({ replyId: id, channel, result: res })) // 返回结果
         } catch (err) {
+            if (consumeCancelled(id)) return;
             // 确保 errMsg 总是一个字符串
             let errMsg = '';
             if (typeof err === 'object' && err !== null) {
@@ -110,21 +179,127 @@
             } else {
                 errMsg = String(err);
             }
-            toGO(newMsg({ replyId: id, channel, args, error: errMsg })) // 返回结果
+            toGO(newMsg({ replyId: id, channel, error: errMsg })) // 返回结果
         }
     }
 
-    // invoke 调用, 有返回值
+    // 调用结束，返回是否已被 GO 端取消
+    function consumeCancelled(id) {
+        delete mb.running[id];
+        if (!mb.cancelled[id]) return false;
+        delete mb.cancelled[id];
+        return true;
+    }
+
+    // invoke 调用, 有返回值，使用默认超时时间
     function invoke(channel, ...args) {
-        const msg = newMsg({ id: randStr(), channel, args });
+        return invokeWithTimeout(DEFAULT_TIMEOUT, channel, ...args)
+    }
+
+    // invoke 调用，指定超时时间（毫秒），为 0 时不限制。超时时间会传递给 GO handler 的 context
+    function invokeWithTimeout(timeout, channel, ...args) {
+        const msg = newMsg({ id: randStr(), channel, args, timeout });
         return withTimeout(new Promise((resolve, reject) => {
             mb.replyWaiting[msg.id] = { resolve, reject }
             toGO(msg)
-        })).finally(() => {
+        }), timeout).finally(() => {
             delete mb.replyWaiting[msg.id]
         })
     }
 
+    // stream 调用，返回异步迭代器，用于 for await...of 逐个接收 GO handler 输出的数据
+    // 接收方按窗口补充额度，消费过慢时 GO 端会暂停输出；调用 cancel()/break 时通知 GO 端取消
+    function stream(channel, ...args) {
+        const id = randStr();
+        const queue = [];
+        let waiter = null;
+        let finished = false;
+        let error = null;
+        let consumed = 0;
+
+        const ack = () => {
+            consumed++;
+            if (consumed < STREAM_WINDOW / 2) return;
+            toGO(newMsg({ replyId: id, stream: true, credit: consumed }))
+            consumed = 0;
+        }
+
+        const settle = (w) => {
+            if (error) {
+                const err = error;
+                error = null;
+                w.reject(err)
+                return;
+            }
+            w.resolve({ value: undefined, done: true })
+        }
+
+        mb.streams[id] = {
+            push(value) {
+                if (finished) return;
+                if (waiter) {
+                    const w = waiter;
+                    waiter = null;
+                    ack();
+                    w.resolve({ value, done: false })
+                    return;
+                }
+                queue.push(value)
+            },
+            end(errMsg) {
+                if (finished) return;
+                finished = true;
+                delete mb.streams[id];
+                if (errMsg) error = new IPCError(errMsg);
+                if (waiter) {
+                    const w = waiter;
+                    waiter = null;
+                    settle(w)
+                }
+            },
+        };
+
+        function cancel() {
+            queue.length = 0;
+            if (finished) return;
+            finished = true;
+            delete mb.streams[id];
+            toGO(newMsg({ cancelId: id }))
+            if (waiter) {
+                const w = waiter;
+                waiter = null;
+                w.resolve({ value: undefined, done: true })
+            }
+        }
+
+        const iterator = {
+            next() {
+                if (queue.length) {
+                    ack();
+                    return Promise.resolve({ value: queue.shift(), done: false })
+                }
+                if (finished) {
+                    return new Promise((resolve, reject) => settle({ resolve, reject }))
+                }
+                return new Promise((resolve, reject) => {
+                    waiter = { resolve, reject }
+                })
+            },
+            return(value) {
+                cancel();
+                return Promise.resolve({ value, done: true })
+            },
+            cancel,
+            [Symbol.asyncIterator]() {
+                return this;
+            },
+        };
+
+        toGO(newMsg({ id, channel, args, stream: true, credit: STREAM_WINDOW }))
+
+        return iterator;
+    }
+
     // sent 调用，没有返回值
     function sent(channel, ...args) {
         const msg = newMsg({ channel, args });
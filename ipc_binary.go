@@ -0,0 +1,237 @@
+package blink
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/epkgs/blink/internal/cast"
+)
+
+// IPC 消息中二进制数据的占位符键名
+//
+// 二进制数据不经过 JSON 传输，消息中以 {"__mb_bin__": 序号} 占位，
+// 实际数据以 ArrayBuffer 作为 JS2GO/GO2JS 函数的额外参数传递
+const JS_BINARY_KEY = "__mb_bin__"
+
+// 提取消息参数、结果中的 []byte，替换为占位符，含有循环引用时返回错误
+func extractMsgBinary(msg IPCMessage) (IPCMessage, [][]byte, error) {
+	e := &binaryExtractor{seen: make(map[jsSeenKey]struct{})}
+
+	if len(msg.Args) > 0 {
+		args, changed, err := e.extract(msg.Args)
+		if err != nil {
+			return msg, nil, err
+		}
+		if changed {
+			msg.Args = args.([]interface{})
+		}
+	}
+
+	if msg.Result != nil {
+		result, changed, err := e.extract(msg.Result)
+		if err != nil {
+			return msg, nil, err
+		}
+		if changed {
+			msg.Result = result
+		}
+	}
+
+	return msg, e.buffers, nil
+}
+
+// 将消息参数、结果中的占位符还原为 []byte
+func restoreMsgBinary(msg *IPCMessage, buffers [][]byte) {
+	if len(buffers) == 0 {
+		return
+	}
+
+	for i, arg := range msg.Args {
+		msg.Args[i] = restoreBinary(arg, buffers)
+	}
+
+	msg.Result = restoreBinary(msg.Result, buffers)
+}
+
+type binaryExtractor struct {
+	buffers [][]byte
+	seen    map[jsSeenKey]struct{} // 正在处理的引用，用于检测循环引用
+}
+
+// 记录正在处理的指针、map、切片，同 jsEncoder.enter
+func (e *binaryExtractor) enter(rv reflect.Value) (leave func(), err error) {
+	key := jsSeenKey{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+
+	if _, exist := e.seen[key]; exist {
+		return nil, fmt.Errorf("ipc: cyclic reference detected in %s", rv.Type())
+	}
+
+	e.seen[key] = struct{}{}
+	return func() {
+		delete(e.seen, key)
+	}, nil
+}
+
+// 递归查找 []byte 并替换为占位符，没有二进制数据时返回原值
+//
+// 含有二进制数据的结构体按 json tag 转为 map，实现了 json.Marshaler/encoding.TextMarshaler 的值保持原样
+func (e *binaryExtractor) extract(val interface{}) (interface{}, bool, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return e.placeholder(v), true, nil
+	case json.Marshaler, encoding.TextMarshaler:
+		return val, false, nil
+	}
+
+	rv := reflect.ValueOf(val)
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return val, false, nil
+		}
+		if rv.Kind() == reflect.Ptr {
+			leave, err := e.enter(rv)
+			if err != nil {
+				return nil, false, err
+			}
+			defer leave()
+		}
+		return e.extract(rv.Elem().Interface())
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return val, false, nil
+		}
+
+		// 自定义的字节切片、字节数组
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(data), rv)
+			return e.placeholder(data), true, nil
+		}
+
+		if rv.Kind() == reflect.Slice {
+			leave, err := e.enter(rv)
+			if err != nil {
+				return nil, false, err
+			}
+			defer leave()
+		}
+
+		items := make([]interface{}, rv.Len())
+		changed := false
+		for i := range items {
+			item, c, err := e.extract(rv.Index(i).Interface())
+			if err != nil {
+				return nil, false, err
+			}
+			items[i] = item
+			changed = changed || c
+		}
+		if !changed {
+			return val, false, nil
+		}
+		return items, true, nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || rv.IsNil() {
+			return val, false, nil
+		}
+
+		leave, err := e.enter(rv)
+		if err != nil {
+			return nil, false, err
+		}
+		defer leave()
+
+		m := make(map[string]interface{}, rv.Len())
+		changed := false
+		iter := rv.MapRange()
+		for iter.Next() {
+			item, c, err := e.extract(iter.Value().Interface())
+			if err != nil {
+				return nil, false, err
+			}
+			m[iter.Key().String()] = item
+			changed = changed || c
+		}
+		if !changed {
+			return val, false, nil
+		}
+		return m, true, nil
+
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		changed := false
+		for _, field := range cast.JSONFields(rv.Type()) {
+			fv, ok := cast.FieldValue(rv, field.Index)
+			if !ok || (field.OmitEmpty && cast.IsEmptyValue(fv)) || !fv.CanInterface() {
+				continue
+			}
+			if field.Quoted {
+				s, ok, err := cast.QuoteValue(fv)
+				if err != nil {
+					return nil, false, err
+				}
+				if ok {
+					m[field.Name] = s
+					continue
+				}
+			}
+			item, c, err := e.extract(fv.Interface())
+			if err != nil {
+				return nil, false, err
+			}
+			m[field.Name] = item
+			changed = changed || c
+		}
+		if !changed {
+			return val, false, nil
+		}
+		return m, true, nil
+	}
+
+	return val, false, nil
+}
+
+func (e *binaryExtractor) placeholder(data []byte) map[string]interface{} {
+	e.buffers = append(e.buffers, data)
+	return map[string]interface{}{JS_BINARY_KEY: len(e.buffers) - 1}
+}
+
+// 递归查找占位符并替换为对应的 []byte
+func restoreBinary(val interface{}, buffers [][]byte) interface{} {
+	switch v := val.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = restoreBinary(item, buffers)
+		}
+	case map[string]interface{}:
+		if idx, ok := binaryIndex(v); ok {
+			if idx >= 0 && idx < len(buffers) {
+				return buffers[idx]
+			}
+			return []byte{}
+		}
+		for key, item := range v {
+			v[key] = restoreBinary(item, buffers)
+		}
+	}
+	return val
+}
+
+func binaryIndex(m map[string]interface{}) (int, bool) {
+	if len(m) != 1 {
+		return 0, false
+	}
+	idx, ok := m[JS_BINARY_KEY].(float64)
+	return int(idx), ok
+}
@@ -0,0 +1,66 @@
+package blink_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/fakebackend"
+)
+
+type cyclicNode struct {
+	Name string      `json:"name"`
+	Data []byte      `json:"data"`
+	Next *cyclicNode `json:"next"`
+}
+
+// 等待回复 id 的消息
+func (page *fakePage) reply(t *testing.T, id string) blink.IPCMessage {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		page.mu.Lock()
+		for _, msg := range page.sent {
+			if msg.ReplyId == id {
+				page.mu.Unlock()
+				return msg
+			}
+		}
+		page.mu.Unlock()
+
+		if time.Now().After(deadline) {
+			t.Fatalf("no reply for %s", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCyclicResultFailsTheCall(t *testing.T) {
+	page := newFakePage(fakebackend.New())
+	app, _ := newTestAppWithBackend(t, page.fake)
+	app.CreateWebWindowPopup()
+
+	app.IPC.Handle("cyclic", func() *cyclicNode {
+		node := &cyclicNode{Name: "a", Data: []byte{1}}
+		node.Next = node
+		return node
+	})
+
+	// 同一个值出现多次不是循环引用
+	app.IPC.Handle("shared", func() []*cyclicNode {
+		node := &cyclicNode{Name: "a", Data: []byte{1}}
+		return []*cyclicNode{node, node}
+	})
+
+	page.toGO(t, blink.IPCMessage{ID: "c1", Channel: "cyclic"})
+	if msg := page.reply(t, "c1"); !strings.Contains(msg.Error, "cyclic") {
+		t.Fatalf("reply error = %q, want cyclic reference error", msg.Error)
+	}
+
+	page.toGO(t, blink.IPCMessage{ID: "c2", Channel: "shared"})
+	if msg := page.reply(t, "c2"); msg.Error != "" {
+		t.Fatalf("reply error = %q", msg.Error)
+	}
+}
@@ -0,0 +1,248 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// 流式调用未指定接收额度时的默认值
+const defaultStreamCredit = 16
+
+var streamWriterType = reflect.TypeOf((*StreamWriter)(nil))
+
+type streamCtxKey struct{}
+
+func withStream(ctx context.Context, writer *StreamWriter) context.Context {
+	return context.WithValue(ctx, streamCtxKey{}, writer)
+}
+
+func streamFromContext(ctx context.Context) (*StreamWriter, bool) {
+	writer, ok := ctx.Value(streamCtxKey{}).(*StreamWriter)
+	return writer, ok
+}
+
+// 流式 IPC 的写入端
+//
+// 接收方（JS 端的 ipc.stream）按额度接收数据，额度用完时 Write 会阻塞，直到接收方消费后补充额度，或调用被取消
+type StreamWriter struct {
+	ctx context.Context
+
+	mu      sync.Mutex
+	credits int
+	signal  chan struct{}
+
+	send      func(data interface{}) error // 为 nil 时为汇总模式
+	collected []interface{}
+}
+
+func newStreamWriter(ctx context.Context, credits int, send func(data interface{}) error) *StreamWriter {
+	if credits <= 0 {
+		credits = defaultStreamCredit
+	}
+
+	return &StreamWriter{
+		ctx:     ctx,
+		credits: credits,
+		signal:  make(chan struct{}, 1),
+		send:    send,
+	}
+}
+
+// 非流式调用时使用，写入的数据汇总为数组作为调用结果
+func newCollectWriter(ctx context.Context) *StreamWriter {
+	return &StreamWriter{
+		ctx:    ctx,
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// 本次调用的 context，接收方取消时随之取消
+func (w *StreamWriter) Context() context.Context {
+	return w.ctx
+}
+
+// 写入一条数据，接收方没有额度时阻塞
+func (w *StreamWriter) Write(data interface{}) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	if w.send == nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		w.collected = append(w.collected, data)
+		return nil
+	}
+
+	if err := w.acquire(); err != nil {
+		return err
+	}
+
+	return w.send(data)
+}
+
+// 按 chunkSize 分块读取 r 并逐块写入，返回写入的字节数
+func (w *StreamWriter) CopyFrom(r io.Reader, chunkSize int) (written int64, err error) {
+	if chunkSize <= 0 {
+		return 0, errors.New("chunkSize must be greater than 0")
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, rErr := r.Read(buf)
+		if n > 0 {
+			if err = w.Write(append([]byte{}, buf[:n]...)); err != nil {
+				return
+			}
+			written += int64(n)
+		}
+
+		if rErr == io.EOF {
+			return written, nil
+		}
+		if rErr != nil {
+			return written, rErr
+		}
+	}
+}
+
+// 等待并占用一个接收额度
+func (w *StreamWriter) acquire() error {
+	for {
+		w.mu.Lock()
+		if w.credits > 0 {
+			w.credits--
+			w.mu.Unlock()
+			return nil
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		case <-w.signal:
+		}
+	}
+}
+
+// 接收方消费后补充额度
+func (w *StreamWriter) addCredit(n int) {
+	if n <= 0 {
+		return
+	}
+
+	w.mu.Lock()
+	w.credits += n
+	w.mu.Unlock()
+
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+// 处理 handler 的返回值
+//
+//   - 返回值为接收 channel 时，逐个写入直到 channel 关闭
+//   - 流式调用时，其他非空返回值作为唯一的一条数据写入
+//   - 非流式调用时，流式 handler 的结果为写入数据的汇总
+func (w *StreamWriter) resolve(res interface{}, isStreamHandler bool) (interface{}, error) {
+	if res != nil {
+		rv := reflect.ValueOf(res)
+		if rv.Kind() == reflect.Chan && rv.Type().ChanDir()&reflect.RecvDir != 0 {
+			if err := w.pump(rv); err != nil {
+				return nil, err
+			}
+			res = nil
+			isStreamHandler = true
+		}
+	}
+
+	if w.send != nil {
+		if res != nil {
+			return nil, w.Write(res)
+		}
+		return nil, nil
+	}
+
+	if !isStreamHandler {
+		return res, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.collected == nil {
+		return []interface{}{}, nil
+	}
+	return w.collected, nil
+}
+
+func (w *StreamWriter) pump(ch reflect.Value) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.ctx.Done())},
+	}
+
+	for {
+		chosen, val, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return w.ctx.Err()
+		}
+		if !ok {
+			return nil
+		}
+		if err := w.Write(val.Interface()); err != nil {
+			return err
+		}
+	}
+}
+
+// JS 端的 ipc.stream 调用
+func (ipc *IPC) invokeStream(ctx context.Context, view *View, msg *IPCMessage, handler ipcHandler, exist bool) {
+	writer := newStreamWriter(ctx, msg.Credit, func(data interface{}) error {
+		return sentMsgToView(view, IPCMessage{
+			ReplyId: msg.ID,
+			Stream:  true,
+			Result:  data,
+		})
+	})
+
+	ipc.streams.Store(msg.ID, writer)
+	defer ipc.streams.Delete(msg.ID)
+
+	result, err := invokeHandler(withStream(ctx, writer), msg.Channel, handler, exist, msg.Args...)
+
+	// JS handler 等不支持流式的 handler，结果作为唯一的一条数据
+	if err == nil && result != nil {
+		err = writer.Write(result)
+	}
+
+	e := ""
+	if err != nil {
+		e = err.Error()
+	}
+
+	_ = sentMsgToView(view, IPCMessage{
+		ReplyId: msg.ID,
+		Stream:  true,
+		Done:    true,
+		Error:   e,
+	})
+}
+
+func (ipc *IPC) addStreamCredit(id string, credit int) {
+	if writer, exist := ipc.streams.Load(id); exist {
+		writer.(*StreamWriter).addCredit(credit)
+	}
+}
+
+func (ipc *IPC) cancelRunning(id string) {
+	if cancel, exist := ipc.running.Load(id); exist {
+		cancel.(context.CancelFunc)()
+	}
+}
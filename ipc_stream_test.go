@@ -0,0 +1,214 @@
+package blink_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/fakebackend"
+)
+
+// 模拟页面的 ipc.js：通过绑定的 JS_JS2GO 发送消息，记录 GO -> JS 的消息
+type fakePage struct {
+	fake *fakebackend.Backend
+
+	mu       sync.Mutex
+	bindings map[string]uintptr // 绑定的函数名 -> 注册时的名称指针
+	incoming string             // 正在发送的 JS -> GO 消息
+	sent     []blink.IPCMessage // GO -> JS 的消息
+}
+
+func newFakePage(fake *fakebackend.Backend) *fakePage {
+	page := &fakePage{fake: fake, bindings: map[string]uintptr{}}
+
+	fake.Handle("wkeJsBindFunction", func(args ...uintptr) uintptr {
+		page.mu.Lock()
+		defer page.mu.Unlock()
+
+		page.bindings[blink.PtrToString(args[0])] = args[0]
+		return 0
+	})
+	fake.Return("jsGetWebView", testViewHandle)
+	fake.Return("jsArgCount", 1)
+	fake.Handle("jsToString", func(args ...uintptr) uintptr {
+		page.mu.Lock()
+		defer page.mu.Unlock()
+
+		return fake.String(page.incoming)
+	})
+	fake.Handle("jsString", func(args ...uintptr) uintptr {
+		var msg blink.IPCMessage
+		_ = json.Unmarshal([]byte(blink.PtrToString(args[1])), &msg)
+
+		page.mu.Lock()
+		defer page.mu.Unlock()
+
+		page.sent = append(page.sent, msg)
+		return 0
+	})
+
+	return page
+}
+
+// 页面调用 JS_JS2GO 发送消息
+func (page *fakePage) toGO(t *testing.T, msg blink.IPCMessage) {
+	t.Helper()
+
+	txt, _ := json.Marshal(msg)
+
+	page.mu.Lock()
+	page.incoming = string(txt)
+	ptr, exist := page.bindings[blink.JS_JS2GO]
+	page.mu.Unlock()
+
+	if !exist {
+		t.Fatal("JS_JS2GO was not bound")
+	}
+
+	// 绑定函数注册时的第一个参数为名称，FireView 按其筛选，回调参数为 (es, param)
+	page.fake.FireView("wkeJsBindFunction", ptr)
+}
+
+// 流式调用 id 收到的消息
+func (page *fakePage) stream(id string) (data []interface{}, done *blink.IPCMessage) {
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	for i, msg := range page.sent {
+		if msg.ReplyId != id || !msg.Stream {
+			continue
+		}
+		if msg.Done {
+			done = &page.sent[i]
+			continue
+		}
+		data = append(data, msg.Result)
+	}
+	return
+}
+
+// 等待收到的数据条数稳定在 want，确认不会再多发
+func (page *fakePage) waitStream(t *testing.T, id string, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		data, _ := page.stream(id)
+		if len(data) > want {
+			t.Fatalf("received %d items, want %d", len(data), want)
+		}
+		if len(data) == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d items, want %d", len(data), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if data, _ := page.stream(id); len(data) != want {
+		t.Fatalf("received %d items without credit, want %d", len(data), want)
+	}
+}
+
+func TestStreamBackpressure(t *testing.T) {
+	page := newFakePage(fakebackend.New())
+	app, _ := newTestAppWithBackend(t, page.fake)
+	app.CreateWebWindowPopup()
+
+	ctxDone := make(chan error, 1)
+	app.IPC.Handle("count", func(w *blink.StreamWriter) error {
+		for i := 0; ; i++ {
+			if err := w.Write(i); err != nil {
+				ctxDone <- err
+				return err
+			}
+		}
+	})
+
+	// 初始额度为 3，写满后 Write 阻塞
+	page.toGO(t, blink.IPCMessage{ID: "s1", Channel: "count", Stream: true, Credit: 3})
+	page.waitStream(t, "s1", 3)
+
+	// 页面消费后补充额度
+	page.toGO(t, blink.IPCMessage{ReplyId: "s1", Stream: true, Credit: 2})
+	page.waitStream(t, "s1", 5)
+
+	data, _ := page.stream("s1")
+	for i, v := range data {
+		if n, ok := v.(float64); !ok || int(n) != i {
+			t.Fatalf("item %d = %v, want %d", i, v, i)
+		}
+	}
+
+	// 页面取消，阻塞中的 Write 返回 context.Canceled
+	page.toGO(t, blink.IPCMessage{CancelId: "s1"})
+
+	select {
+	case err := <-ctxDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Write() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Write() still blocked after cancel")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, done := page.stream("s1"); done != nil {
+			if done.Error == "" {
+				t.Fatal("done message has no error")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no done message after cancel")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamChannelHandler(t *testing.T) {
+	page := newFakePage(fakebackend.New())
+	app, _ := newTestAppWithBackend(t, page.fake)
+	app.CreateWebWindowPopup()
+
+	app.IPC.Handle("letters", func() <-chan string {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			for _, s := range []string{"a", "b", "c", "d"} {
+				ch <- s
+			}
+		}()
+		return ch
+	})
+
+	page.toGO(t, blink.IPCMessage{ID: "s2", Channel: "letters", Stream: true, Credit: 2})
+	page.waitStream(t, "s2", 2)
+
+	page.toGO(t, blink.IPCMessage{ReplyId: "s2", Stream: true, Credit: 2})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		data, done := page.stream("s2")
+		if done != nil {
+			if done.Error != "" {
+				t.Fatalf("done error = %q", done.Error)
+			}
+			if len(data) != 4 || data[3] != "d" {
+				t.Fatalf("data = %v", data)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stream not done, data = %v", data)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
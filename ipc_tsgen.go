@@ -0,0 +1,57 @@
+package blink
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/epkgs/blink/pkg/tsgen"
+)
+
+// 注入参数，不占用调用参数
+func skipInjectedParam(t reflect.Type) (skip bool, stream bool) {
+	switch t {
+	case contextType, viewType:
+		return true, false
+	case streamWriterType:
+		return true, true
+	}
+	return false, false
+}
+
+func addTypeScriptChannels(gen *tsgen.Generator, types map[string]reflect.Type) {
+	for channel, fnType := range types {
+		gen.Add(tsgen.FromFunc(channel, fnType, skipInjectedParam))
+	}
+}
+
+// 根据已注册的全局 GO handler 创建 TypeScript 声明生成器
+func (ipc *IPC) TypeScript() *tsgen.Generator {
+	ipc.mu.RLock()
+	defer ipc.mu.RUnlock()
+
+	gen := tsgen.New()
+	addTypeScriptChannels(gen, ipc.types)
+	return gen
+}
+
+// 将已注册的全局 GO handler 生成 TypeScript 声明（.d.ts）并写入 w
+func (ipc *IPC) WriteTypeScript(w io.Writer) error {
+	_, err := ipc.TypeScript().WriteTo(w)
+	return err
+}
+
+// 根据全局及当前 view 的 GO handler 创建 TypeScript 声明生成器
+func (vi *ViewIPC) TypeScript() *tsgen.Generator {
+	gen := vi.ipc.TypeScript()
+
+	vi.ipc.mu.RLock()
+	defer vi.ipc.mu.RUnlock()
+
+	addTypeScriptChannels(gen, vi.types)
+	return gen
+}
+
+func (vi *ViewIPC) WriteTypeScript(w io.Writer) error {
+	_, err := vi.TypeScript().WriteTo(w)
+	return err
+}
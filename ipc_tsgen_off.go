@@ -0,0 +1,8 @@
+//go:build !blink_tsgen
+
+package blink
+
+// 正常构建时不生成声明，需要时调用 IPC.WriteTypeScript
+func (ipc *IPC) generateTypeScript() (generated bool, err error) {
+	return false, nil
+}
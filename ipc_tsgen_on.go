@@ -0,0 +1,32 @@
+//go:build blink_tsgen
+
+package blink
+
+import (
+	"errors"
+	"os"
+
+	"github.com/epkgs/blink/pkg/tsgen"
+)
+
+// 以 blink_tsgen 构建时，KeepRunning 将声明写入 cmd/blink-tsgen 通过环境变量指定的文件，不进入主循环
+func (ipc *IPC) generateTypeScript() (generated bool, err error) {
+	out := os.Getenv(tsgen.EnvOutput)
+	if out == "" {
+		return true, errors.New(tsgen.EnvOutput + " is not set, build with the " + tsgen.BuildTag + " tag only through cmd/blink-tsgen")
+	}
+
+	gen := ipc.TypeScript()
+	if ns := os.Getenv(tsgen.EnvNamespace); ns != "" {
+		gen.Namespace = ns
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return true, err
+	}
+	defer f.Close()
+
+	_, err = gen.WriteTo(f)
+	return true, err
+}
@@ -0,0 +1,135 @@
+package blink
+
+import (
+	"context"
+	"reflect"
+)
+
+var viewType = reflect.TypeOf((*View)(nil))
+
+type viewCtxKey struct{}
+
+func withView(ctx context.Context, view *View) context.Context {
+	return context.WithValue(ctx, viewCtxKey{}, view)
+}
+
+// 获取 IPC 调用方所在的 view，GO 端直接调用全局 handler 时不存在
+func ViewFromContext(ctx context.Context) (*View, bool) {
+	view, ok := ctx.Value(viewCtxKey{}).(*View)
+	return view, ok && view != nil
+}
+
+// 限定在单个 view 内的 IPC
+//
+//   - Invoke/Sent 优先调用该 view 页面注册的 JS handler，其次是该 view 的 GO handler，最后是全局 GO handler
+//   - Handle 注册的 GO handler 仅响应该 view 页面的调用
+type ViewIPC struct {
+	ipc  *IPC
+	view *View
+
+	handlers   map[string]ipcHandler // 仅作用于该 view 的 GO handler
+	types      map[string]reflect.Type
+	jsHandlers map[string]ipcHandler // 该 view 页面注册的 JS handler
+}
+
+func newViewIPC(ipc *IPC, view *View) *ViewIPC {
+	vi := &ViewIPC{
+		ipc:  ipc,
+		view: view,
+
+		handlers:   make(map[string]ipcHandler),
+		types:      make(map[string]reflect.Type),
+		jsHandlers: make(map[string]ipcHandler),
+	}
+
+	view.OnDestroy(func() {
+		ipc.removeView(view)
+	})
+
+	// 页面刷新、跳转后原页面注册的 JS handler 已失效，由新页面重新注册
+	view.OnWillReleaseScriptContext(func(frame WkeWebFrameHandle, context uintptr, worldId int) {
+		if worldId != 0 || !view.IsMainFrame(frame) {
+			return
+		}
+		ipc.removeView(view)
+	})
+
+	return vi
+}
+
+// 获取仅作用于当前 view 的 IPC
+func (v *View) IPC() *ViewIPC {
+	return v.ipc
+}
+
+// 调用 handler，使用默认超时时间
+func (vi *ViewIPC) Invoke(channel string, args ...interface{}) (interface{}, error) {
+	ctx, cancel := vi.ipc.withTimeout(vi.ipc.mb.Ctx)
+	defer cancel()
+
+	return vi.InvokeContext(ctx, channel, args...)
+}
+
+// 调用 handler，ctx 取消或超时后立即返回错误
+func (vi *ViewIPC) InvokeContext(ctx context.Context, channel string, args ...interface{}) (interface{}, error) {
+	handler, exist := vi.lookup(channel)
+	return invokeHandler(withView(ctx, vi.view), channel, handler, exist, args...)
+}
+
+func (vi *ViewIPC) Sent(channel string, args ...interface{}) error {
+	handler, exist := vi.lookup(channel)
+	return sentHandler(withView(vi.ipc.mb.Ctx, vi.view), channel, handler, exist, args...)
+}
+
+// 注册仅响应当前 view 页面调用的 GO handler，参数规则同 IPC.Handle
+func (vi *ViewIPC) Handle(channel string, handler Callback) {
+	h := newGoHandler(channel, handler)
+
+	vi.ipc.mu.Lock()
+	defer vi.ipc.mu.Unlock()
+
+	vi.handlers[channel] = h
+	vi.types[channel] = reflect.TypeOf(handler)
+}
+
+func (vi *ViewIPC) HasChannel(channel string) (exist bool) {
+	_, exist = vi.lookup(channel)
+	return
+}
+
+func (vi *ViewIPC) registerJSHandler(channel string, handler ipcHandler) {
+	vi.ipc.mu.Lock()
+	defer vi.ipc.mu.Unlock()
+
+	vi.jsHandlers[channel] = handler
+}
+
+// GO 端调用时的查找顺序：JS handler > view GO handler > 全局 GO handler
+func (vi *ViewIPC) lookup(channel string) (ipcHandler, bool) {
+	vi.ipc.mu.RLock()
+	defer vi.ipc.mu.RUnlock()
+
+	if handler, exist := vi.jsHandlers[channel]; exist {
+		return handler, true
+	}
+
+	if handler, exist := vi.handlers[channel]; exist {
+		return handler, true
+	}
+
+	handler, exist := vi.ipc.handlers[channel]
+	return handler, exist
+}
+
+// JS 端调用时的查找顺序：view GO handler > 全局 handler
+func (vi *ViewIPC) lookupForJS(channel string) (ipcHandler, bool) {
+	vi.ipc.mu.RLock()
+	handler, exist := vi.handlers[channel]
+	vi.ipc.mu.RUnlock()
+
+	if exist {
+		return handler, true
+	}
+
+	return vi.ipc.lookup(channel)
+}
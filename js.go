@@ -1,17 +1,24 @@
 package blink
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
-	"time"
 	"unsafe"
+
+	"github.com/epkgs/blink/internal/cast"
+	"github.com/epkgs/blink/internal/log"
 )
 
 type BindFunctionCallback func(es JsExecState)
 
 type JS struct {
 	mb *Blink
+
+	evals evalPending
 }
 
 func newJS(blink *Blink) *JS {
@@ -19,6 +26,8 @@ func newJS(blink *Blink) *JS {
 		mb: blink,
 	}
 
+	js.registerEvalSettle()
+
 	return js
 }
 
@@ -29,7 +38,142 @@ func (js *JS) bindFunction(funcName string, funcArgCount uint32, callback BindFu
 
 		return 0
 	}
-	_, _, _ = js.mb.CallFunc("wkeJsBindFunction", StringToPtr(funcName), CallbackToPtr(cb), 0, uintptr(funcArgCount))
+	_, _, _ = js.mb.CallFunc("wkeJsBindFunction", StringToPtr(funcName), js.mb.NewCallback(cb), 0, uintptr(funcArgCount))
+}
+
+var (
+	jsExecStateType = reflect.TypeOf(JsExecState(0))
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// 绑定同步调用的原生函数到 window[name]，需要在页面加载之前调用
+//
+//   - fn 的参数由 JS 参数通过 ToGoValue 转换后按参数类型解码，规则同 IPC.Handle；第一个参数可以为 JsExecState
+//   - 返回值通过 ToJsValue 转换；最后一个返回值为 error 且不为 nil 时，在 JS 中抛出异常
+//   - fn 在 UI 线程中执行，不宜耗时过长；耗时的调用请使用 IPC
+func (js *JS) BindFunction(name string, fn interface{}) {
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		panic(fmt.Sprintf("BindFunction %s: fn must be a function", name))
+	}
+
+	fnType := fnVal.Type()
+
+	offset := 0
+	if fnType.NumIn() > 0 && fnType.In(0) == jsExecStateType {
+		offset = 1
+	}
+
+	argCount := fnType.NumIn() - offset
+	if fnType.IsVariadic() {
+		argCount--
+	}
+
+	var cb WkeJsNativeFunction = func(es JsExecState, param uintptr) (result uintptr) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = uintptr(js.ThrowException(es, fmt.Sprintf("%s: %v", name, r)))
+			}
+		}()
+
+		in, err := js.bindArgs(es, fnType, offset)
+		if err != nil {
+			return uintptr(js.ThrowException(es, fmt.Sprintf("%s: %s", name, err.Error())))
+		}
+
+		out := fnVal.Call(in)
+
+		if n := len(out); n > 0 && fnType.Out(n-1) == errorType {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				return uintptr(js.ThrowException(es, err.Error()))
+			}
+			out = out[:n-1]
+		}
+
+		if len(out) == 0 {
+			return uintptr(js.Undefined())
+		}
+
+		ret, err := js.ToJsValueE(es, out[0].Interface())
+		if err != nil {
+			return uintptr(js.ThrowException(es, fmt.Sprintf("%s: %s", name, err.Error())))
+		}
+		return uintptr(ret)
+	}
+
+	_, _, _ = js.mb.CallFunc("wkeJsBindFunction", StringToPtr(name), js.mb.NewCallback(cb), 0, uintptr(argCount))
+}
+
+// 将 JS 参数转换为 fn 的参数
+func (js *JS) bindArgs(es JsExecState, fnType reflect.Type, offset int) ([]reflect.Value, error) {
+	count := int(js.ArgCount(es))
+
+	in := make([]reflect.Value, 0, fnType.NumIn())
+	if offset > 0 {
+		in = append(in, reflect.ValueOf(es))
+	}
+
+	fixed := fnType.NumIn()
+	if fnType.IsVariadic() {
+		fixed--
+	}
+
+	for i := offset; i < fixed; i++ {
+		idx := i - offset
+		if idx >= count {
+			in = append(in, reflect.Zero(fnType.In(i)))
+			continue
+		}
+
+		val, err := js.decodeArg(es, idx, fnType.In(i))
+		if err != nil {
+			return nil, fmt.Errorf("argument[%d]: %w", idx, err)
+		}
+		in = append(in, val)
+	}
+
+	if fnType.IsVariadic() {
+		elem := fnType.In(fixed).Elem()
+		for idx := fixed - offset; idx < count; idx++ {
+			val, err := js.decodeArg(es, idx, elem)
+			if err != nil {
+				return nil, fmt.Errorf("argument[%d]: %w", idx, err)
+			}
+			in = append(in, val)
+		}
+	}
+
+	return in, nil
+}
+
+func (js *JS) decodeArg(es JsExecState, idx int, t reflect.Type) (reflect.Value, error) {
+	arg, err := js.ToGoValueE(es, js.Arg(es, uint32(idx)))
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return cast.Decode(t, arg)
+}
+
+// 在 JS 中抛出异常，原生函数应将返回值作为自身的返回值
+func (js *JS) ThrowException(es JsExecState, message string) JsValue {
+	r, _, _ := js.mb.CallFunc("jsThrowException", uintptr(es), StringToPtr(message))
+	return JsValue(r)
+}
+
+// 绑定 window 上属性的 getter，callback 的返回值即为属性值
+func (js *JS) bindGetter(name string, callback func(es JsExecState) JsValue) {
+	var cb WkeJsNativeFunction = func(es JsExecState, param uintptr) (voidRes uintptr) {
+		return uintptr(callback(es))
+	}
+	_, _, _ = js.mb.CallFunc("wkeJsBindGetter", StringToPtr(name), js.mb.NewCallback(cb), 0)
+}
+
+// 绑定 window 上属性的 setter，新的值为第一个参数。callback 可返回 ThrowException 的结果以抛出异常
+func (js *JS) bindSetter(name string, callback func(es JsExecState, value JsValue) JsValue) {
+	var cb WkeJsNativeFunction = func(es JsExecState, param uintptr) (voidRes uintptr) {
+		return uintptr(callback(es, js.Arg(es, 0)))
+	}
+	_, _, _ = js.mb.CallFunc("wkeJsBindSetter", StringToPtr(name), js.mb.NewCallback(cb), 0)
 }
 
 // 获取页面主frame的jsExecState
@@ -55,13 +199,19 @@ func (js *JS) Undefined() JsValue {
 	return JsValue(r)
 }
 
+func (js *JS) Null() JsValue {
+	r, _, _ := js.mb.CallFunc("jsNull")
+	return JsValue(r)
+}
+
 func (js *JS) Int(value int32) JsValue {
 	r, _, _ := js.mb.CallFunc("jsInt", uintptr(value))
 	return JsValue(r)
 }
 
+// 通过字符串传递浮点数，避免浮点参数的调用约定问题
 func (js *JS) Double(value float64) JsValue {
-	r, _, _ := js.mb.CallFunc("jsDouble", uintptr(value))
+	r, _, _ := js.mb.CallFunc("jsDoubleString", StringToPtr(strconv.FormatFloat(value, 'g', -1, 64)))
 	return JsValue(r)
 }
 
@@ -152,6 +302,35 @@ func (js *JS) String(es JsExecState, value string) JsValue {
 	r, _, _ := js.mb.CallFunc("jsString", uintptr(es), StringToPtr(value))
 	return JsValue(r)
 }
+
+// 创建 ArrayBuffer，数据会被复制
+func (js *JS) ArrayBuffer(es JsExecState, data []byte) JsValue {
+	var ptr = uintptr(0)
+	if len(data) > 0 {
+		ptr = uintptr(unsafe.Pointer(&data[0]))
+	}
+
+	r, _, _ := js.mb.CallFunc("jsArrayBuffer", uintptr(es), ptr, uintptr(len(data)))
+	runtime.KeepAlive(data)
+	return JsValue(r)
+}
+
+// 获取 ArrayBuffer 的数据，value 不是 ArrayBuffer 时返回 nil
+func (js *JS) GetArrayBuffer(es JsExecState, value JsValue) []byte {
+	p, _, _ := js.mb.CallFunc("jsGetArrayBuffer", uintptr(es), uintptr(value))
+	if p == 0 {
+		return nil
+	}
+	defer js.mb.CallFunc("wkeFreeMemBuf", p)
+
+	buf := (*WkeMemBuf)(unsafe.Pointer(p))
+	if buf.Data == nil || buf.Length == 0 {
+		return []byte{}
+	}
+
+	return append([]byte{}, unsafe.Slice((*byte)(buf.Data), buf.Length)...)
+}
+
 func (js *JS) EmptyArray(es JsExecState) JsValue {
 	r, _, _ := js.mb.CallFunc("jsEmptyArray", uintptr(es))
 	return JsValue(r)
@@ -171,9 +350,17 @@ func (js *JS) SetLength(es JsExecState, object JsValue, length uint32) {
 	_, _, _ = js.mb.CallFunc("jsSetLength", uintptr(es), uintptr(object), uintptr(length))
 }
 
+// 通过字符串获取浮点数，避免浮点返回值的调用约定问题
 func (js *JS) ToDouble(es JsExecState, value JsValue) float64 {
-	p, _, _ := js.mb.CallFunc("jsToDouble", uintptr(es), uintptr(value))
-	return float64(p)
+	p, _, _ := js.mb.CallFunc("jsToDoubleString", uintptr(es), uintptr(value))
+	if p == 0 {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(PtrToString(p)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
 }
 
 func (js *JS) ToBoolean(es JsExecState, value JsValue) bool {
@@ -202,110 +389,22 @@ func (js *JS) Call(es JsExecState, fn, thisValue JsValue, args []JsValue) JsValu
 	return JsValue(r)
 }
 
+// GO 值转为 JS 值，不支持的类型记录错误并返回 undefined，规则见 ToJsValueE
 func (js *JS) ToJsValue(es JsExecState, value interface{}) JsValue {
-	if value == nil {
+	v, err := js.ToJsValueE(es, value)
+	if err != nil {
+		log.Error(err.Error())
 		return js.Undefined()
 	}
-	switch val := value.(type) {
-	case int:
-		return js.Int(int32(val))
-	case int8:
-		return js.Int(int32(val))
-	case int16:
-		return js.Int(int32(val))
-	case int32:
-		return js.Int(val)
-	case int64:
-		return js.Double(float64(val))
-	case uint:
-		return js.Int(int32(val))
-	case uint8:
-		return js.Int(int32(val))
-	case uint16:
-		return js.Int(int32(val))
-	case uint32:
-		return js.Int(int32(val))
-	case uint64:
-		return js.Double(float64(val))
-	case float32:
-		return js.Double(float64(val))
-	case float64:
-		return js.Double(val)
-	case bool:
-		return js.Boolean(val)
-	case string:
-		return js.String(es, val)
-	case time.Time:
-		return js.Double(float64(val.Unix()))
-	default:
-		break
-	}
-	rt := reflect.TypeOf(value)
-	rv := reflect.ValueOf(value)
-	switch rv.Kind() {
-	case reflect.Slice, reflect.Array:
-		length := rv.Len()
-		arr := js.EmptyArray(es)
-		js.SetLength(es, arr, uint32(length))
-		for i := 0; i < length; i++ {
-			v := js.ToJsValue(es, rv.Index(i).Interface())
-			js.SetAt(es, arr, uint32(i), v)
-		}
-		return arr
-	case reflect.Map:
-		obj := js.EmptyObject(es)
-		kv := rv.MapRange()
-		for kv.Next() && kv.Key().Kind() == reflect.String {
-			k := kv.Key().Interface().(string)
-			v := js.ToJsValue(es, kv.Value().Interface())
-			js.Set(es, obj, k, v)
-		}
-		return obj
-	case reflect.Struct:
-		obj := js.EmptyObject(es)
-		for i := 0; i < rv.NumField(); i++ {
-			f := rt.Field(i)
-			if strings.ToUpper(f.Name)[0] == f.Name[0] {
-				fname := rt.Field(i).Name
-				fvalue := rv.Field(i).Interface()
-				v := js.ToJsValue(es, fvalue)
-				js.Set(es, obj, fname, v)
-			}
-		}
-		return obj
-	}
-	// TODO: 移除 panic，应该使用返回 error
-	panic("不支持的go类型：" + rv.Kind().String() + "(" + rv.Type().String() + ")")
+	return v
 }
 
+// JS 值转为 GO 值，不支持的类型记录错误并返回 nil，规则见 ToGoValueE
 func (js *JS) ToGoValue(es JsExecState, value JsValue) interface{} {
-	switch js.TypeOf(value) {
-	case JsType_NULL, JsType_UNDEFINED:
+	v, err := js.ToGoValueE(es, value)
+	if err != nil {
+		log.Error(err.Error())
 		return nil
-	case JsType_NUMBER:
-		return js.ToDouble(es, value)
-	case JsType_BOOLEAN:
-		return js.ToBoolean(es, value)
-	case JsType_STRING:
-		return js.ToTempString(es, value)
-	case JsType_ARRAY:
-		length := js.GetLength(es, value)
-		ps := make([]interface{}, length)
-		for i := 0; i < length; i++ {
-			v := js.GetAt(es, value, uint32(i))
-			ps[i] = js.ToGoValue(es, v)
-		}
-		return ps
-	case JsType_OBJECT:
-		ps := make(map[string]interface{})
-		keys := js.GetKeys(es, value)
-		for _, k := range keys {
-			v := js.Get(es, value, k)
-			ps[k] = js.ToGoValue(es, v)
-		}
-		return ps
-	default:
-		// TODO: 移除 panic，应该使用返回 error
-		panic("不支持的js类型：" + strconv.Itoa(int(value)))
 	}
+	return v
 }
@@ -0,0 +1,547 @@
+package blink
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/epkgs/blink/internal/cast"
+)
+
+// JS 的安全整数范围 ±(2^53-1)
+const jsMaxSafeInteger = 1<<53 - 1
+
+// JS -> GO 转换时的最大嵌套深度，超出时视为循环引用
+const jsMaxDepth = 100
+
+var (
+	jsValueType       = reflect.TypeOf(JsValue(0))
+	jsFunctionType    = reflect.TypeOf((*JsFunction)(nil))
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ToJsValueE 的转换配置
+type ToJsConfig struct {
+	// 超出安全整数范围的 int64/uint64 转为 BigInt，默认转为字符串
+	BigInt bool
+}
+
+// GO 值转为 JS 值，不支持的类型返回错误
+//
+//   - 结构体按 json tag 转为对象，支持 omitempty；json.Marshaler 按其 JSON 结果转换，encoding.TextMarshaler 转为字符串
+//   - time.Time 转为 Date，[]byte 转为 ArrayBuffer
+//   - nil 指针、map、切片转为 null，未指定类型的 nil 转为 undefined
+//   - JsValue、*JsFunction 原样传递
+//   - 检测到循环引用时返回错误
+func (js *JS) ToJsValueE(es JsExecState, value interface{}, setups ...func(*ToJsConfig)) (result JsValue, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = 0, fmt.Errorf("cannot convert %T to js value: %v", value, r)
+		}
+	}()
+
+	enc := &jsEncoder{
+		js:   js,
+		es:   es,
+		seen: make(map[jsSeenKey]struct{}),
+	}
+	for _, setup := range setups {
+		setup(&enc.config)
+	}
+
+	if value == nil {
+		return js.Undefined(), nil
+	}
+
+	return enc.encode(reflect.ValueOf(value), "")
+}
+
+type jsSeenKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type jsEncoder struct {
+	js     *JS
+	es     JsExecState
+	config ToJsConfig
+	seen   map[jsSeenKey]struct{}
+}
+
+func (e *jsEncoder) encode(rv reflect.Value, path string) (JsValue, error) {
+	js := e.js
+
+	if !rv.IsValid() {
+		return js.Null(), nil
+	}
+
+	switch rv.Type() {
+	case jsValueType:
+		return rv.Interface().(JsValue), nil
+	case jsFunctionType:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		return rv.Interface().(*JsFunction).Value(), nil
+	case timeType:
+		return e.date(rv.Interface().(time.Time)), nil
+	}
+
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return js.Null(), nil
+	}
+
+	if rv.Type().Implements(jsonMarshalerType) {
+		return e.marshaler(rv.Interface().(json.Marshaler), path)
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return 0, e.error(path, rv, err)
+		}
+		return js.String(e.es, string(text)), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return js.Boolean(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.uint(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return js.Double(rv.Float()), nil
+	case reflect.String:
+		return js.String(e.es, rv.String()), nil
+	case reflect.Interface:
+		return e.encode(rv.Elem(), path)
+	case reflect.Ptr:
+		leave, err := e.enter(rv, path)
+		if err != nil {
+			return 0, err
+		}
+		defer leave()
+		return e.encode(rv.Elem(), path)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return js.ArrayBuffer(e.es, rv.Bytes()), nil
+		}
+		leave, err := e.enter(rv, path)
+		if err != nil {
+			return 0, err
+		}
+		defer leave()
+		return e.array(rv, path)
+	case reflect.Array:
+		return e.array(rv, path)
+	case reflect.Map:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		leave, err := e.enter(rv, path)
+		if err != nil {
+			return 0, err
+		}
+		defer leave()
+		return e.object(rv, path)
+	case reflect.Struct:
+		return e.structObject(rv, path)
+	}
+
+	return 0, e.error(path, rv, fmt.Errorf("unsupported type"))
+}
+
+// 记录正在转换的引用，用于检测循环引用
+func (e *jsEncoder) enter(rv reflect.Value, path string) (leave func(), err error) {
+	key := jsSeenKey{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+
+	if _, exist := e.seen[key]; exist {
+		return nil, e.error(path, rv, fmt.Errorf("cyclic reference detected"))
+	}
+
+	e.seen[key] = struct{}{}
+	return func() {
+		delete(e.seen, key)
+	}, nil
+}
+
+func (e *jsEncoder) int(n int64) JsValue {
+	if n >= math.MinInt32 && n <= math.MaxInt32 {
+		return e.js.Int(int32(n))
+	}
+	if n >= -jsMaxSafeInteger && n <= jsMaxSafeInteger {
+		return e.js.Double(float64(n))
+	}
+	return e.bigInt(strconv.FormatInt(n, 10))
+}
+
+func (e *jsEncoder) uint(n uint64) JsValue {
+	if n <= math.MaxInt32 {
+		return e.js.Int(int32(n))
+	}
+	if n <= jsMaxSafeInteger {
+		return e.js.Double(float64(n))
+	}
+	return e.bigInt(strconv.FormatUint(n, 10))
+}
+
+func (e *jsEncoder) bigInt(digits string) JsValue {
+	if e.config.BigInt {
+		return e.js.Eval(e.es, "return BigInt('"+digits+"')")
+	}
+	return e.js.String(e.es, digits)
+}
+
+// time.Time 转为 Date，精确到毫秒
+func (e *jsEncoder) date(t time.Time) JsValue {
+	ms := float64(t.UnixNano()) / float64(time.Millisecond)
+	return e.js.Eval(e.es, "return new Date("+strconv.FormatFloat(math.Floor(ms), 'f', -1, 64)+")")
+}
+
+func (e *jsEncoder) marshaler(m json.Marshaler, path string) (JsValue, error) {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return 0, e.error(path, reflect.ValueOf(m), err)
+	}
+
+	var val interface{}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return 0, e.error(path, reflect.ValueOf(m), err)
+	}
+
+	if val == nil {
+		return e.js.Null(), nil
+	}
+
+	return e.encode(reflect.ValueOf(val), path)
+}
+
+func (e *jsEncoder) array(rv reflect.Value, path string) (JsValue, error) {
+	js := e.js
+
+	length := rv.Len()
+	arr := js.EmptyArray(e.es)
+	js.SetLength(e.es, arr, uint32(length))
+	for i := 0; i < length; i++ {
+		v, err := e.encode(rv.Index(i), path+"["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return 0, err
+		}
+		js.SetAt(e.es, arr, uint32(i), v)
+	}
+	return arr, nil
+}
+
+// map 的键支持字符串、整数及 encoding.TextMarshaler
+func (e *jsEncoder) object(rv reflect.Value, path string) (JsValue, error) {
+	js := e.js
+
+	obj := js.EmptyObject(e.es)
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return 0, e.error(path, rv, err)
+		}
+
+		v, err := e.encode(iter.Value(), joinPath(path, key))
+		if err != nil {
+			return 0, err
+		}
+		js.Set(e.es, obj, key, v)
+	}
+	return obj, nil
+}
+
+func (e *jsEncoder) structObject(rv reflect.Value, path string) (JsValue, error) {
+	js := e.js
+
+	obj := js.EmptyObject(e.es)
+	for _, field := range cast.JSONFields(rv.Type()) {
+		fv, ok := cast.FieldValue(rv, field.Index)
+		if !ok || (field.OmitEmpty && cast.IsEmptyValue(fv)) {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+
+		if field.Quoted {
+			s, ok, err := cast.QuoteValue(fv)
+			if err != nil {
+				return 0, e.error(fieldPath, fv, err)
+			}
+			if ok {
+				js.Set(e.es, obj, field.Name, js.String(e.es, s))
+				continue
+			}
+		}
+		v, err := e.encode(fv, fieldPath)
+		if err != nil {
+			return 0, err
+		}
+		js.Set(e.es, obj, field.Name, v)
+	}
+	return obj, nil
+}
+
+func (e *jsEncoder) error(path string, rv reflect.Value, err error) error {
+	if path == "" {
+		path = "value"
+	}
+	return fmt.Errorf("cannot convert %s (%s) to js value: %w", path, rv.Type(), err)
+}
+
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// JS 值转为 GO 值，不支持的类型返回错误
+//
+//   - null、undefined 转为 nil，数字转为 float64，数组转为 []interface{}，对象转为 map[string]interface{}
+//   - Date 转为 time.Time，ArrayBuffer 及 TypedArray 转为 []byte
+//   - 函数转为 *JsFunction，可以在 GO 中调用
+//   - 嵌套过深（通常为循环引用）时返回错误
+func (js *JS) ToGoValueE(es JsExecState, value JsValue) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("cannot convert js value to go value: %v", r)
+		}
+	}()
+
+	return js.toGoValue(es, value, 0)
+}
+
+func (js *JS) toGoValue(es JsExecState, value JsValue, depth int) (interface{}, error) {
+	if depth > jsMaxDepth {
+		return nil, fmt.Errorf("js value nested deeper than %d, possibly a cyclic reference", jsMaxDepth)
+	}
+
+	switch typ := js.TypeOf(value); typ {
+	case JsType_NULL, JsType_UNDEFINED:
+		return nil, nil
+	case JsType_NUMBER:
+		return js.ToDouble(es, value), nil
+	case JsType_BOOLEAN:
+		return js.ToBoolean(es, value), nil
+	case JsType_STRING:
+		return js.ToString(es, value), nil
+	case JsType_FUNCTION:
+		return newJsFunction(js, es, value), nil
+	case JsType_ARRAY:
+		length := js.GetLength(es, value)
+		items := make([]interface{}, length)
+		for i := 0; i < length; i++ {
+			item, err := js.toGoValue(es, js.GetAt(es, value, uint32(i)), depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			items[i] = item
+		}
+		return items, nil
+	case JsType_OBJECT:
+		return js.toGoObject(es, value, depth)
+	default:
+		return nil, fmt.Errorf("unsupported js type %d", typ)
+	}
+}
+
+func (js *JS) toGoObject(es JsExecState, value JsValue, depth int) (interface{}, error) {
+	switch tag := js.objectTag(es, value); tag {
+	case "[object Date]":
+		getTime := js.Get(es, value, "getTime")
+		ms := js.ToDouble(es, js.Call(es, getTime, value, nil))
+		if math.IsNaN(ms) {
+			return time.Time{}, nil
+		}
+		return time.Unix(0, int64(ms*float64(time.Millisecond))), nil
+
+	case "[object ArrayBuffer]":
+		return js.GetArrayBuffer(es, value), nil
+
+	case "[object Int8Array]", "[object Uint8Array]", "[object Uint8ClampedArray]",
+		"[object Int16Array]", "[object Uint16Array]", "[object Int32Array]", "[object Uint32Array]",
+		"[object Float32Array]", "[object Float64Array]", "[object DataView]":
+		data := js.GetArrayBuffer(es, js.Get(es, value, "buffer"))
+		offset := int(js.ToDouble(es, js.Get(es, value, "byteOffset")))
+		length := int(js.ToDouble(es, js.Get(es, value, "byteLength")))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("invalid %s range", tag)
+		}
+		return data[offset : offset+length], nil
+	}
+
+	m := make(map[string]interface{})
+	for _, key := range js.GetKeys(es, value) {
+		item, err := js.toGoValue(es, js.Get(es, value, key), depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		m[key] = item
+	}
+	return m, nil
+}
+
+// Object.prototype.toString 的结果，如 [object Date]
+func (js *JS) objectTag(es JsExecState, value JsValue) string {
+	proto := js.Get(es, js.GetGlobal(es, "Object"), "prototype")
+	toString := js.Get(es, proto, "toString")
+	return js.ToString(es, js.Call(es, toString, value, nil))
+}
+
+// 可在 GO 中调用的 JS 函数
+//
+// 创建时会增加引用计数，不再使用时应调用 Release。
+// 创建时的 jsExecState 只在当次回调中有效，调用时使用所在 view 的全局 jsExecState
+type JsFunction struct {
+	js    *JS
+	view  WkeHandle
+	value JsValue
+}
+
+func newJsFunction(js *JS, es JsExecState, value JsValue) *JsFunction {
+	_, _, _ = js.mb.CallFunc("jsAddRef", uintptr(es), uintptr(value))
+
+	return &JsFunction{
+		js:    js,
+		view:  js.GetWebView(es),
+		value: value,
+	}
+}
+
+// 函数本身的 JS 值
+func (f *JsFunction) Value() JsValue {
+	return f.value
+}
+
+// 调用函数，参数通过 ToJsValueE 转换，返回值通过 ToGoValueE 转换；函数抛出异常时返回 *JsException
+//
+// 可在任意 goroutine 中调用，转换、调用、读取异常在同一个 miniblink 任务中完成，之间不会插入其他 JS 执行
+func (f *JsFunction) Call(args ...interface{}) (result interface{}, err error) {
+	f.onUIThread(func() {
+		result, err = f.call(args)
+	})
+	return
+}
+
+func (f *JsFunction) call(args []interface{}) (interface{}, error) {
+	js := f.js
+
+	es := js.GlobalExec(f.view)
+	if es == 0 {
+		return nil, errors.New("js function: view has been destroyed")
+	}
+
+	values := make([]JsValue, len(args))
+	for i, arg := range args {
+		v, err := js.ToJsValueE(es, arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument[%d]: %w", i, err)
+		}
+		values[i] = v
+	}
+
+	result := js.Call(es, f.value, js.Undefined(), values)
+	if err := js.LastException(es); err != nil {
+		return nil, err
+	}
+
+	return js.ToGoValueE(es, result)
+}
+
+// 释放引用，之后不能再调用
+func (f *JsFunction) Release() {
+	f.onUIThread(func() {
+		if es := f.js.GlobalExec(f.view); es != 0 {
+			_, _, _ = f.js.mb.CallFunc("jsReleaseRef", uintptr(es), uintptr(f.value))
+		}
+	})
+}
+
+// 在 miniblink 线程中执行 fn，不在时加入任务队列并等待完成
+func (f *JsFunction) onUIThread(fn func()) {
+	if f.js.mb.threadID == currentThreadID() {
+		fn()
+	} else {
+		<-f.js.mb.AddJob(fn)
+	}
+}
+
+// JS 执行时抛出的异常
+type JsException struct {
+	Message            string
+	SourceLine         string
+	ScriptResourceName string
+	LineNumber         int
+	StartColumn        int
+	EndColumn          int
+	Stack              string
+}
+
+func (e *JsException) Error() string {
+	if e.ScriptResourceName != "" {
+		return fmt.Sprintf("js exception: %s (%s:%d:%d)", e.Message, e.ScriptResourceName, e.LineNumber, e.StartColumn)
+	}
+	return "js exception: " + e.Message
+}
+
+// 获取最近一次 JS 执行抛出的异常，没有异常时返回 nil
+func (js *JS) LastException(es JsExecState) *JsException {
+	p, _, _ := js.mb.CallFunc("jsGetLastErrorIfException", uintptr(es))
+	if p == 0 {
+		return nil
+	}
+
+	info := (*JsExceptionInfo)(unsafe.Pointer(p))
+
+	return &JsException{
+		Message:            ptrToStringOrEmpty(info.Message),
+		SourceLine:         ptrToStringOrEmpty(info.SourceLine),
+		ScriptResourceName: ptrToStringOrEmpty(info.ScriptResourceName),
+		LineNumber:         int(info.LineNumber),
+		StartColumn:        int(info.StartColumn),
+		EndColumn:          int(info.EndColumn),
+		Stack:              ptrToStringOrEmpty(info.CallstackString),
+	}
+}
+
+func ptrToStringOrEmpty(p uintptr) string {
+	if p == 0 {
+		return ""
+	}
+	return PtrToString(p)
+}
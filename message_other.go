@@ -0,0 +1,29 @@
+//go:build !windows
+
+package blink
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// 非 windows 平台没有 windows 消息循环，仅用于配合 WithNativeBackend 在 CI 中测试
+func (mb *Blink) LoopWinMessage() {}
+
+// 用于判断是否在 miniblink 线程中
+//
+// 非 windows 平台没有可移植的线程 id，以 goroutine id 代替：miniblink 的消息循环固定在一个 goroutine 中执行
+func currentThreadID() uint32 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	// "goroutine 123 [running]: ..."
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return uint32(id)
+}
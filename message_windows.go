@@ -0,0 +1,36 @@
+//go:build windows
+
+package blink
+
+import (
+	"sync"
+
+	"github.com/lxn/win"
+	"golang.org/x/sys/windows"
+)
+
+var winMsgOnce sync.Once
+
+func (mb *Blink) LoopWinMessage() {
+	winMsgOnce.Do(func() {
+
+		msg := &win.MSG{}
+
+		mb.AddLoop(func() {
+
+			if win.GetMessage(msg, 0, 0, 0) <= 0 {
+				return
+			}
+
+			win.TranslateMessage(msg)
+
+			win.DispatchMessage(msg)
+
+		})
+	})
+}
+
+// 当前线程的 id，用于判断是否在 miniblink 线程中
+func currentThreadID() uint32 {
+	return windows.GetCurrentThreadId()
+}
@@ -0,0 +1,271 @@
+package blink
+
+import (
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"unsafe"
+)
+
+// 网络请求，对 wkeNetJob 的封装
+//
+// 仅在 OnRequest / OnLoadUrlBegin 回调期间有效，不要在回调之外保存使用
+type Request struct {
+	Job  WkeNetJob
+	View *View
+
+	mb *Blink
+}
+
+func newRequest(view *View, job WkeNetJob) *Request {
+	return &Request{Job: job, View: view, mb: view.mb}
+}
+
+// 请求的 url，调用 ChangeURL 后返回修改后的 url
+func (r *Request) URL() string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetUrlByJob", uintptr(r.Job))
+	return PtrToString(p)
+}
+
+// 请求方法，如 GET、POST，无法识别时返回空字符串
+func (r *Request) Method() string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetRequestMethod", uintptr(r.Job))
+	switch WkeRequestType(p) {
+	case WkeRequestType_Get:
+		return http.MethodGet
+	case WkeRequestType_Post:
+		return http.MethodPost
+	case WkeRequestType_Put:
+		return http.MethodPut
+	}
+	return ""
+}
+
+func (r *Request) Referrer() string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetReferrer", uintptr(r.Job))
+	return PtrToString(p)
+}
+
+// 获取单个请求头
+func (r *Request) Header(key string) string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetHTTPHeaderField", uintptr(r.Job), StringToPtr(key))
+	return PtrToString(p)
+}
+
+// 获取全部请求头
+func (r *Request) Headers() http.Header {
+	p, _, _ := r.mb.CallFunc("wkeNetGetRawHttpHead", uintptr(r.Job))
+	return parseRawHead(p)
+}
+
+// 设置请求头，如添加 Authorization
+func (r *Request) SetHeader(key, value string) {
+	_, _, _ = r.mb.CallFunc("wkeNetSetHTTPHeaderField", uintptr(r.Job), StringToWCharPtr(key), StringToWCharPtr(value), BoolToPtr(false))
+}
+
+// 修改请求的 url，如按环境替换 API 域名
+func (r *Request) ChangeURL(url string) {
+	_, _, _ = r.mb.CallFunc("wkeNetChangeRequestUrl", uintptr(r.Job), StringToPtr(url))
+}
+
+// 取消请求
+func (r *Request) Cancel() {
+	_, _, _ = r.mb.CallFunc("wkeNetCancelRequest", uintptr(r.Job))
+}
+
+// 请求体的一个元素
+type PostBodyElement struct {
+	Type       WkeHttBodyElementType
+	Data       []byte // Type 为 WkeHttBodyElementTypeData 时有效
+	FilePath   string // Type 为 WkeHttBodyElementTypeFile 时有效
+	FileStart  int64
+	FileLength int64 // -1 表示到文件末尾
+}
+
+// 与 C 的 wkePostBodyElement 内存布局一致
+type postBodyElement struct {
+	Size       int32
+	Type       int32
+	Data       *WkeMemBuf
+	FilePath   WkeString
+	FileStart  int64
+	FileLength int64
+}
+
+// 与 C 的 wkePostBodyElements 内存布局一致
+type postBodyElements struct {
+	Size        int32
+	Element     uintptr // wkePostBodyElement**
+	ElementSize uintptr
+	IsDirty     bool
+}
+
+// 获取请求体的各个元素，没有请求体时返回 nil
+func (r *Request) PostBody() []PostBodyElement {
+	p, _, _ := r.mb.CallFunc("wkeNetGetPostBody", uintptr(r.Job))
+	if p == 0 {
+		return nil
+	}
+	defer r.mb.CallFunc("wkeNetFreePostBodyElements", p)
+
+	elements := (*postBodyElements)(unsafe.Pointer(p))
+	if elements.Element == 0 || elements.ElementSize == 0 {
+		return nil
+	}
+
+	result := make([]PostBodyElement, 0, elements.ElementSize)
+	for _, ptr := range unsafe.Slice((**postBodyElement)(unsafe.Pointer(elements.Element)), elements.ElementSize) {
+		if ptr == nil {
+			continue
+		}
+
+		element := PostBodyElement{
+			Type:       WkeHttBodyElementType(ptr.Type),
+			FileStart:  ptr.FileStart,
+			FileLength: ptr.FileLength,
+		}
+
+		switch element.Type {
+		case WkeHttBodyElementTypeData:
+			if ptr.Data != nil && ptr.Data.Data != nil && ptr.Data.Length > 0 {
+				element.Data = append([]byte{}, unsafe.Slice((*byte)(ptr.Data.Data), ptr.Data.Length)...)
+			}
+		case WkeHttBodyElementTypeFile:
+			if ptr.FilePath != 0 {
+				element.FilePath = r.mb.GetString(ptr.FilePath)
+			}
+		}
+
+		result = append(result, element)
+	}
+
+	return result
+}
+
+// 获取完整的请求体，文件元素会读取对应的文件内容
+func (r *Request) Body() ([]byte, error) {
+	var body []byte
+
+	for _, element := range r.PostBody() {
+		if element.Type != WkeHttBodyElementTypeFile {
+			body = append(body, element.Data...)
+			continue
+		}
+
+		data, err := readFileRange(element.FilePath, element.FileStart, element.FileLength)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, data...)
+	}
+
+	return body, nil
+}
+
+func readFileRange(path string, start, length int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if start > 0 {
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	if length < 0 {
+		return io.ReadAll(f)
+	}
+	return io.ReadAll(io.LimitReader(f, length))
+}
+
+// 同一个 job 的响应，用于直接返回数据
+func (r *Request) Response() *Response {
+	return newResponse(r.View, r.Job)
+}
+
+// 网络响应，对 wkeNetJob 的封装
+//
+// 仅在 OnResponse / OnLoadUrlBegin 等回调期间有效，不要在回调之外保存使用
+type Response struct {
+	Job  WkeNetJob
+	View *View
+
+	mb *Blink
+}
+
+func newResponse(view *View, job WkeNetJob) *Response {
+	return &Response{Job: job, View: view, mb: view.mb}
+}
+
+func (r *Response) URL() string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetUrlByJob", uintptr(r.Job))
+	return PtrToString(p)
+}
+
+// 响应状态码，跳转时为 3xx，0 表示未知
+//
+// 来自 OnOtherLoad 的 WKE_DID_GET_RESPONSE_DETAILS / WKE_DID_GET_REDIRECT_REQUEST，收到响应后才有值，
+// 可在同一个 job 的 OnLoadUrlEnd、OnLoadUrlFinish、OnLoadUrlFail 回调中读取，请求结束后清除；
+// 由 Resource、OnRequestAsync 等直接返回数据的请求为 0
+func (r *Response) StatusCode() int {
+	return r.View._jobInfos.get(r.Job).statusCode
+}
+
+// 获取单个响应头
+func (r *Response) Header(key string) string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetHTTPHeaderFieldFromResponse", uintptr(r.Job), StringToPtr(key))
+	return PtrToString(p)
+}
+
+// 获取全部响应头
+func (r *Response) Headers() http.Header {
+	p, _, _ := r.mb.CallFunc("wkeNetGetRawResponseHead", uintptr(r.Job))
+	return parseRawHead(p)
+}
+
+// 设置响应头
+func (r *Response) SetHeader(key, value string) {
+	_, _, _ = r.mb.CallFunc("wkeNetSetHTTPHeaderField", uintptr(r.Job), StringToWCharPtr(key), StringToWCharPtr(value), BoolToPtr(true))
+}
+
+func (r *Response) MIMEType() string {
+	p, _, _ := r.mb.CallFunc("wkeNetGetMIMEType", uintptr(r.Job), 0)
+	return PtrToString(p)
+}
+
+func (r *Response) SetMIMEType(mimeType string) {
+	r.mb.NetSetMIMEType(r.Job, mimeType)
+}
+
+// 直接设置响应数据，在 OnRequest / OnLoadUrlBegin 中调用时需让回调返回 true；在 OnLoadUrlEnd 中调用则替换网络数据
+func (r *Response) SetData(data []byte) {
+	r.mb.NetSetData(r.Job, data)
+}
+
+// 解析 wkeNetGetRawHttpHead / wkeNetGetRawResponseHead 返回的链表，链表按 key、value 交替排列
+func parseRawHead(p uintptr) http.Header {
+	header := make(http.Header)
+
+	var key string
+	var hasKey bool
+	for p != 0 {
+		item := (*WkeSlist)(unsafe.Pointer(p))
+		p = item.Next
+
+		str := PtrToString(item.Str)
+
+		if !hasKey {
+			key, hasKey = str, true
+			continue
+		}
+
+		header.Add(textproto.CanonicalMIMEHeaderKey(key), str)
+		hasKey = false
+	}
+
+	return header
+}
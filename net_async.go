@@ -0,0 +1,196 @@
+package blink
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/epkgs/blink/internal/log"
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+// 异步拦截的默认超时时间，超时后自动放行请求
+const DefaultAsyncJobTimeout = 30 * time.Second
+
+var ErrJobFinished = errors.New("net job already finished")
+
+type OnRequestAsyncCallback func(job *AsyncJob)
+
+// 异步拦截的请求，可在任意 goroutine 中调用 Respond / Continue / Fail 完成，只能完成一次
+//
+// 完成之前 Request 的方法均可使用，调用会通过 CallFunc 转到 miniblink 线程执行；完成后 job 由 miniblink 释放，不能再使用。
+// 挂起的 job 只按以下一种方式结束：Respond、Continue 通过 wkeNetContinueJob 提交，Fail 通过 wkeNetCancelRequest 取消，不再提交
+type AsyncJob struct {
+	*Request
+
+	url string // 挂起时的 url，完成后仍可用于日志
+
+	locker   sync.Mutex
+	finished bool
+	timer    *time.Timer
+	done     chan struct{}
+}
+
+func newAsyncJob(view *View, job WkeNetJob, url string) *AsyncJob {
+	return &AsyncJob{
+		Request: newRequest(view, job),
+		url:     url,
+		done:    make(chan struct{}),
+	}
+}
+
+// 请求发出前触发，filter 返回 true 的请求会被挂起，然后在新的 goroutine 中调用 callback
+//
+// filter 在 miniblink 线程中同步执行，为 nil 时挂起所有请求；callback 中需调用 Respond / Continue / Fail，
+// 超过 DefaultAsyncJobTimeout（可通过 AsyncJob.SetTimeout 修改）未完成的请求会自动放行。
+// 与其他 OnLoadUrlBegin 回调按注册顺序执行，被请求过滤、Resource 等先注册的回调拦截的请求不会到达这里
+func (v *View) OnRequestAsync(filter func(req *Request) bool, callback OnRequestAsyncCallback) (stop func()) {
+	return v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+
+		asyncJob := newAsyncJob(v, job, url)
+
+		if filter != nil && !filter(asyncJob.Request) {
+			return false
+		}
+
+		if held, _, _ := v.mb.CallFunc("wkeNetHoldJobToAsynCommit", uintptr(job)); held == 0 {
+			log.Error("hold net job failed: %s", url)
+			return false
+		}
+
+		v._netJobs.hold(job)
+		asyncJob.SetTimeout(DefaultAsyncJobTimeout)
+
+		utils.Go(func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("OnRequestAsync callback panic: %v", r)
+					_ = asyncJob.Fail(fmt.Errorf("%v", r))
+				}
+			}()
+
+			callback(asyncJob)
+		}, nil)
+
+		return true
+	})
+}
+
+// 设置超时时间，从调用时开始计算，超时后自动调用 Continue
+func (job *AsyncJob) SetTimeout(timeout time.Duration) {
+	job.locker.Lock()
+	defer job.locker.Unlock()
+
+	if job.finished {
+		return
+	}
+
+	if job.timer != nil {
+		job.timer.Stop()
+	}
+
+	job.timer = time.AfterFunc(timeout, func() {
+		if err := job.Continue(); err == nil {
+			log.Debug("net job timeout, continued: %s", job.url)
+		}
+	})
+}
+
+// 请求完成时关闭
+func (job *AsyncJob) Done() <-chan struct{} {
+	return job.done
+}
+
+// 标记为已完成，返回 false 表示之前已经完成
+func (job *AsyncJob) finish() bool {
+	job.locker.Lock()
+	defer job.locker.Unlock()
+
+	if job.finished {
+		return false
+	}
+
+	job.finished = true
+	if job.timer != nil {
+		job.timer.Stop()
+	}
+
+	return true
+}
+
+// 提交挂起的请求，continued 为 true 时请求继续由网络层加载，否则请求到此结束
+func (job *AsyncJob) commit(continued bool) {
+	_, _, _ = job.mb.CallFunc("wkeNetContinueJob", uintptr(job.Job))
+
+	if continued {
+		job.View._netJobs.release(job.Job)
+	} else {
+		job.View.endNetJob(job.Job)
+	}
+
+	close(job.done)
+}
+
+// 直接返回响应
+//
+// 与 Resource 的 http.Handler 相同，miniblink 无法修改状态码：3xx 且带 Location 时改为加载 Location，其它状态码页面中看到的均为 200
+func (job *AsyncJob) Respond(status int, header http.Header, body []byte) error {
+	if !job.finish() {
+		return ErrJobFinished
+	}
+
+	url := job.URL()
+
+	rec := newResponseRecorder()
+	for key, values := range header {
+		rec.header[http.CanonicalHeaderKey(key)] = append([]string{}, values...)
+	}
+	rec.WriteHeader(status)
+	_, _ = rec.body.Write(body)
+
+	if location := rec.header.Get("Location"); status >= 300 && status < 400 && location != "" {
+		target, err := resolveLocation(url, location)
+		if err == nil {
+			job.ChangeURL(target)
+			job.commit(true)
+			return nil
+		}
+		log.Error("invalid redirect location %q of %s: %s", location, url, err.Error())
+	}
+
+	writeRecorder(job.Response(), url, rec)
+	job.commit(false)
+
+	return nil
+}
+
+// 放行请求，继续由网络层加载，放行前可修改请求头、url
+func (job *AsyncJob) Continue() error {
+	if !job.finish() {
+		return ErrJobFinished
+	}
+
+	job.commit(true)
+
+	return nil
+}
+
+// 取消请求，页面中表现为网络错误
+func (job *AsyncJob) Fail(err error) error {
+	if !job.finish() {
+		return ErrJobFinished
+	}
+
+	if err != nil {
+		log.Debug("net job failed: %s, %s", job.url, err.Error())
+	}
+
+	// 取消即结束挂起，取消后的 job 不能再 wkeNetContinueJob
+	job.Cancel()
+	job.View.endNetJob(job.Job)
+	close(job.done)
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package blink_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+)
+
+func TestAsyncJobFinish(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Return("wkeNetHoldJobToAsynCommit", 1)
+	view := app.CreateWebWindowPopup()
+
+	jobs := make(chan *blink.AsyncJob, 2)
+	view.OnRequestAsync(nil, func(job *blink.AsyncJob) {
+		jobs <- job
+	})
+
+	if results := fireLoadUrlBegin(fake, "http://fail.test/", 1); results[0] != 1 {
+		t.Fatalf("held request results = %v", results)
+	}
+	job := <-jobs
+
+	if err := job.Fail(errors.New("denied")); err != nil {
+		t.Fatalf("Fail() = %v", err)
+	}
+	if err := job.Continue(); !errors.Is(err, blink.ErrJobFinished) {
+		t.Fatalf("Continue() after Fail = %v", err)
+	}
+	if calls := fake.Calls("wkeNetCancelRequest"); len(calls) != 1 || calls[0].Args[0] != 1 {
+		t.Fatalf("wkeNetCancelRequest calls = %v", calls)
+	}
+	if fake.Called("wkeNetContinueJob") {
+		t.Fatal("failed job was committed")
+	}
+
+	fireLoadUrlBegin(fake, "http://timeout.test/", 2)
+	job = <-jobs
+	job.SetTimeout(10 * time.Millisecond)
+
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		t.Fatal("job was not continued after timeout")
+	}
+	if calls := fake.Calls("wkeNetContinueJob"); len(calls) != 1 || calls[0].Args[0] != 2 {
+		t.Fatalf("wkeNetContinueJob calls = %v", calls)
+	}
+}
@@ -0,0 +1,41 @@
+package blink_test
+
+import (
+	"testing"
+
+	"github.com/epkgs/blink"
+)
+
+func TestResponseStatusCode(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	var resp *blink.Response
+	view.OnRequest(func(req *blink.Request) bool {
+		resp = req.Response()
+		return false
+	})
+
+	codes := make(chan int, 2)
+	view.OnLoadUrlFinish(func(url string, job blink.WkeNetJob, length int) {
+		codes <- resp.StatusCode()
+	})
+
+	fireLoadUrlBegin(fake, "http://redirect.test/", 1)
+	fake.FireView("wkeOnOtherLoad", testViewHandle, uintptr(blink.WKE_DID_GET_REDIRECT_REQUEST), 0)
+	fireResponseDetails(fake, 1, 302)
+	fireResponseDetails(fake, 1, 404)
+	fake.FireView("wkeOnLoadUrlFinish", testViewHandle, 0, 1, 0)
+
+	if code := <-codes; code != 404 {
+		t.Fatalf("StatusCode() = %d, want 404", code)
+	}
+
+	// 请求结束后清除，复用的 job 不会得到之前的状态码
+	fireLoadUrlBegin(fake, "http://unknown.test/", 1)
+	fake.FireView("wkeOnLoadUrlFinish", testViewHandle, 0, 1, 0)
+
+	if code := <-codes; code != 0 {
+		t.Fatalf("StatusCode() = %d, want 0", code)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package alert
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	iconError uint32 = iota + 1
+	iconInfo
+	iconWarning
+)
+
+// 非 windows 平台没有消息框，输出到标准错误
+func Alert(flag uint32, title string, content string) int32 {
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", title, content)
+	return 0
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package alert
+
+import (
+	"syscall"
+
+	"github.com/lxn/win"
+)
+
+func strToWcharPtr(s string) *uint16 {
+	ptr, _ := syscall.UTF16PtrFromString(s)
+	return ptr
+}
+
+const (
+	iconError   = win.MB_ICONERROR
+	iconInfo    = win.MB_ICONINFORMATION
+	iconWarning = win.MB_ICONWARNING
+)
+
+func Alert(flag uint32, title string, content string) int32 {
+	return win.MessageBox(0, strToWcharPtr(content), strToWcharPtr(title), flag|win.MB_OK|win.MB_SYSTEMMODAL)
+}
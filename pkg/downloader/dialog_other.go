@@ -0,0 +1,8 @@
+//go:build !windows
+
+package downloader
+
+// 非 windows 平台没有保存文件对话框，直接使用默认路径
+func openSaveFileDialog(filePath string) (filepath string, ok bool) {
+	return filePath, true
+}
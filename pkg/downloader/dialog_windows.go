@@ -0,0 +1,39 @@
+//go:build windows
+
+package downloader
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/lxn/win"
+)
+
+func openSaveFileDialog(filePath string) (filepath string, ok bool) {
+	var ofn win.OPENFILENAME
+	buf := make([]uint16, syscall.MAX_PATH) // 假设路径可能更长，增加缓冲区大小
+	ofn.LStructSize = uint32(unsafe.Sizeof(ofn))
+	ofn.LpstrFile = &buf[0]
+	ofn.NMaxFile = uint32(len(buf))
+	ofn.Flags = win.OFN_OVERWRITEPROMPT
+
+	// UTF16FromString 不支持中间带 \0 的字符串，所以需要手动拼接
+	filter, _ := syscall.UTF16FromString("所有文件（*.*）")
+	filterM, _ := syscall.UTF16FromString("*.*")
+	filter = append(filter, filterM...)
+	filter = append(filter, 0)
+	ofn.LpstrFilter = &filter[0]
+
+	// 转换文件名到UTF-16，并检查错误
+	if utf16FileName, err := syscall.UTF16FromString(filePath); err == nil {
+		copy(buf, utf16FileName)
+	}
+
+	ok = win.GetSaveFileName(&ofn)
+
+	if ok {
+		filepath = syscall.UTF16ToString(buf)
+	}
+
+	return
+}
@@ -17,14 +17,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
-	"unsafe"
 
 	"github.com/epkgs/blink/internal/log"
 	"github.com/epkgs/blink/pkg/alert"
 	"github.com/jlaffaye/ftp"
-	"github.com/lxn/win"
 )
 
 type IDownloadChunkCallback func(res *http.Response, index uint64) error
@@ -34,6 +32,9 @@ type IHttpDownloadingInterceptor func(job *Job, res *http.Response) io.Reader
 type IFtpDownloadingInterceptor func(job *Job, res *ftp.Response) io.Reader
 type IBeforeSaveFileInterceptor func(job *Job)
 
+// 下载进度，total 为 0 表示无法获取文件大小。多线程下载时会在各个下载线程中调用
+type IProgressCallback func(job *Job, downloaded, total uint64)
+
 type IInterceptors struct {
 	BeforeDownload  IBeforeDownloadInterceptor
 	HttpDownloading IHttpDownloadingInterceptor
@@ -54,7 +55,11 @@ type Config struct {
 	OverwriteFile        bool // 是否覆盖已存在的文件，默认false
 	InsecureSkipVerify   bool // 跳过证书验证，默认false
 
+	Proxy func(*http.Request) (*netUrl.URL, error) // 代理，与 http.Transport.Proxy 相同，默认不使用代理。不支持 ftp 下载
+
 	Interceptors IInterceptors // 拦截器
+
+	OnProgress IProgressCallback // 下载进度回调，每次写入临时文件后调用，默认 nil
 }
 
 func (conf Config) Clone() Config {
@@ -88,6 +93,8 @@ type Job struct {
 	isSupportRange bool
 	isFtp          bool
 
+	downloaded atomic.Int64 // 已写入临时文件的字节数，分块下载失败重试时会扣除
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -402,14 +409,10 @@ func (job *Job) downloadFtp() (tmpFiles []string, err error) {
 	defer file.Close()
 	tmpFiles = append(tmpFiles, file.Name())
 
-	buf, err := io.ReadAll(job.Interceptors.FtpDownloading(job, res))
-	if err != nil {
-		return tmpFiles, err
-	}
-
-	_, err = file.Write(buf)
+	progress := &progressWriter{job: job}
+	_, err = io.Copy(io.MultiWriter(file, progress), job.Interceptors.FtpDownloading(job, res))
 
-	return tmpFiles, nil
+	return tmpFiles, err
 }
 
 func (job *Job) sentRequest(req *http.Request) (*http.Response, error) {
@@ -430,6 +433,7 @@ func (job *Job) sentRequest(req *http.Request) (*http.Response, error) {
 			// 忽略证书验证
 			InsecureSkipVerify: job.InsecureSkipVerify,
 		},
+		Proxy: job.Proxy,
 	}
 
 	client := &http.Client{
@@ -669,10 +673,47 @@ func (job *Job) downloadChunk(ctx context.Context, index uint64, byteRange strin
 	job.logDebug("[ 线程 %d ] 下载到临时文件 %s", index+1, tmpFile)
 
 	// 将HTTP响应的Body内容写入到文件中
-	_, err = io.Copy(file, reader)
+	progress := &progressWriter{job: job}
+	_, err = io.Copy(io.MultiWriter(file, progress), reader)
+	if err != nil {
+		progress.discard() // 分块会重新下载
+	}
 	return tmpFile, err
 }
 
+// 已下载的字节数
+func (job *Job) Downloaded() uint64 {
+	return uint64(job.downloaded.Load())
+}
+
+func (job *Job) addProgress(n int64) {
+	downloaded := job.downloaded.Add(n)
+
+	if job.OnProgress != nil {
+		job.OnProgress(job, uint64(downloaded), job.FileSize)
+	}
+}
+
+// 统计写入临时文件的字节数
+type progressWriter struct {
+	job     *Job
+	written int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.job.addProgress(int64(len(p)))
+	return len(p), nil
+}
+
+// 扣除已统计的字节数
+func (w *progressWriter) discard() {
+	if w.written > 0 {
+		w.job.addProgress(-w.written)
+		w.written = 0
+	}
+}
+
 func getFileNameByResponse(res *http.Response) string {
 	contentDisposition := res.Header.Get("Content-Disposition")
 	if contentDisposition != "" {
@@ -691,35 +732,6 @@ func getFileNameByResponse(res *http.Response) string {
 	return filepath.Base(res.Request.URL.Path)
 }
 
-func openSaveFileDialog(filePath string) (filepath string, ok bool) {
-	var ofn win.OPENFILENAME
-	buf := make([]uint16, syscall.MAX_PATH) // 假设路径可能更长，增加缓冲区大小
-	ofn.LStructSize = uint32(unsafe.Sizeof(ofn))
-	ofn.LpstrFile = &buf[0]
-	ofn.NMaxFile = uint32(len(buf))
-	ofn.Flags = win.OFN_OVERWRITEPROMPT
-
-	// UTF16FromString 不支持中间带 \0 的字符串，所以需要手动拼接
-	filter, _ := syscall.UTF16FromString("所有文件（*.*）")
-	filterM, _ := syscall.UTF16FromString("*.*")
-	filter = append(filter, filterM...)
-	filter = append(filter, 0)
-	ofn.LpstrFilter = &filter[0]
-
-	// 转换文件名到UTF-16，并检查错误
-	if utf16FileName, err := syscall.UTF16FromString(filePath); err == nil {
-		copy(buf, utf16FileName)
-	}
-
-	ok = win.GetSaveFileName(&ofn)
-
-	if ok {
-		filepath = syscall.UTF16ToString(buf)
-	}
-
-	return
-}
-
 func (job *Job) logDebug(tpl string, vars ...interface{}) {
 	log.Debug(fmt.Sprintf("[下载任务 %d ]: ", job.id)+tpl, vars...)
 }
@@ -0,0 +1,277 @@
+// 内存中的 miniblink 原生调用后端，用于在没有 DLL 的环境下测试 View/IPC/JS 逻辑
+//
+//	fake := fakebackend.New()
+//	fake.Return("wkeGetURL", urlPtr)
+//	app := blink.NewApp(blink.WithNativeBackend(fake))
+//	view := app.CreateWebWindowPopup()
+//	fake.FireView("wkeOnDocumentReady2", uintptr(view.Hwnd), frame)
+package fakebackend
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// 回调指针的起始值，避免和普通参数混淆
+const callbackBase uintptr = 0x0cb00000
+
+// 一次导出函数调用的记录
+type Call struct {
+	Name string
+	Args []uintptr
+}
+
+// 自定义导出函数的返回值
+type Handler func(args ...uintptr) (r1 uintptr)
+
+// 通过导出函数注册的回调
+type registration struct {
+	view  uintptr
+	param uintptr
+	fn    reflect.Value
+}
+
+type Backend struct {
+	mu sync.Mutex
+
+	calls     []Call
+	handlers  map[string]Handler
+	callbacks []reflect.Value
+
+	registered map[string][]registration
+
+	strings [][]byte // String 分配的内存，在 Backend 的生命周期内保持有效
+
+	released bool
+}
+
+func New() *Backend {
+	return &Backend{
+		handlers:   make(map[string]Handler),
+		registered: make(map[string][]registration),
+	}
+}
+
+// 固定导出函数的返回值
+func (b *Backend) Return(funcName string, r1 uintptr) *Backend {
+	return b.Handle(funcName, func(args ...uintptr) uintptr {
+		return r1
+	})
+}
+
+// 自定义导出函数的处理逻辑，未设置的函数返回 0
+func (b *Backend) Handle(funcName string, handler Handler) *Backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[funcName] = handler
+	return b
+}
+
+func (b *Backend) Call(funcName string, args ...uintptr) (r1 uintptr, r2 uintptr, err error) {
+	b.mu.Lock()
+
+	if b.released {
+		b.mu.Unlock()
+		return 0, 0, errors.New("backend released")
+	}
+
+	b.calls = append(b.calls, Call{Name: funcName, Args: append([]uintptr{}, args...)})
+
+	// 参数中含有回调指针的，视为注册回调。约定 wkeOnXXX(view, callback, param) 的参数顺序
+	for i, arg := range args {
+		fn, ok := b.lookup(arg)
+		if !ok {
+			continue
+		}
+
+		reg := registration{fn: fn}
+		if i > 0 {
+			reg.view = args[0]
+		}
+		if i+1 < len(args) {
+			reg.param = args[i+1]
+		}
+		b.registered[funcName] = append(b.registered[funcName], reg)
+	}
+
+	handler, exist := b.handlers[funcName]
+
+	b.mu.Unlock()
+
+	if exist {
+		r1 = handler(args...)
+	}
+
+	return r1, 0, nil
+}
+
+// 以 0 结尾的字符串，用作触发回调、导出函数返回值中的 const char*
+//
+// 内存由 Backend 持有，在其生命周期内有效，blink.PtrToString 只在这块内存内读取，-race 下同样可用
+func (b *Backend) String(s string) uintptr {
+	buf := append([]byte(s), 0)
+
+	b.mu.Lock()
+	b.strings = append(b.strings, buf)
+	b.mu.Unlock()
+
+	return uintptr(unsafe.Pointer(&buf[0]))
+}
+
+func (b *Backend) NewCallback(fn interface{}) uintptr {
+	val := reflect.ValueOf(fn)
+	if val.Kind() != reflect.Func {
+		panic(fmt.Sprintf("callback must be a function, got %T", fn))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.callbacks = append(b.callbacks, val)
+
+	return callbackBase + uintptr(len(b.callbacks)-1)
+}
+
+func (b *Backend) Release() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.released = true
+	return nil
+}
+
+func (b *Backend) lookup(ptr uintptr) (reflect.Value, bool) {
+	if ptr < callbackBase || ptr >= callbackBase+uintptr(len(b.callbacks)) {
+		return reflect.Value{}, false
+	}
+	return b.callbacks[ptr-callbackBase], true
+}
+
+// 获取调用记录，不指定函数名时返回全部
+func (b *Backend) Calls(funcNames ...string) []Call {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(funcNames) == 0 {
+		return append([]Call{}, b.calls...)
+	}
+
+	var calls []Call
+	for _, call := range b.calls {
+		for _, name := range funcNames {
+			if call.Name == name {
+				calls = append(calls, call)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+// 指定的导出函数是否被调用过
+func (b *Backend) Called(funcName string) bool {
+	return len(b.Calls(funcName)) > 0
+}
+
+// 清空调用记录
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls = nil
+}
+
+// 触发通过 funcName 注册的所有回调，args 按回调函数的参数顺序传入
+//
+// 返回每个回调的返回值
+func (b *Backend) Fire(funcName string, args ...uintptr) []uintptr {
+	var results []uintptr
+	for _, reg := range b.registrations(funcName) {
+		results = append(results, invoke(reg.fn, args))
+	}
+	return results
+}
+
+// 触发某个 view 通过 funcName 注册的回调，会自动补上回调前两个参数 (view, param)
+//
+//	fake.FireView("wkeOnLoadUrlBegin", hwnd, urlPtr, job)
+func (b *Backend) FireView(funcName string, view uintptr, args ...uintptr) []uintptr {
+	var results []uintptr
+	for _, reg := range b.registrations(funcName) {
+		if reg.view != view {
+			continue
+		}
+		results = append(results, invoke(reg.fn, append([]uintptr{view, reg.param}, args...)))
+	}
+	return results
+}
+
+// 是否有通过 funcName 注册的回调
+func (b *Backend) HasCallback(funcName string) bool {
+	return len(b.registrations(funcName)) > 0
+}
+
+func (b *Backend) registrations(funcName string) []registration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]registration{}, b.registered[funcName]...)
+}
+
+// 以 uintptr 参数调用 GO 回调函数，参数不足时补 0
+func invoke(fn reflect.Value, args []uintptr) uintptr {
+	fnType := fn.Type()
+
+	in := make([]reflect.Value, fnType.NumIn())
+	for i := range in {
+		var arg uintptr
+		if i < len(args) {
+			arg = args[i]
+		}
+		in[i] = toValue(fnType.In(i), arg)
+	}
+
+	out := fn.Call(in)
+	if len(out) == 0 {
+		return 0
+	}
+
+	return fromValue(out[0])
+}
+
+func toValue(t reflect.Type, arg uintptr) reflect.Value {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(int64(arg)).Convert(t)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.ValueOf(uint64(arg)).Convert(t)
+	case reflect.Bool:
+		return reflect.ValueOf(arg != 0).Convert(t)
+	case reflect.Ptr:
+		return reflect.NewAt(t.Elem(), *(*unsafe.Pointer)(unsafe.Pointer(&arg)))
+	case reflect.UnsafePointer:
+		return reflect.ValueOf(*(*unsafe.Pointer)(unsafe.Pointer(&arg))).Convert(t)
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+func fromValue(v reflect.Value) uintptr {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uintptr(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintptr(v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,67 @@
+package fakebackend
+
+import "testing"
+
+func TestCallRecordsAndHandles(t *testing.T) {
+	b := New()
+	b.Return("wkeVersion", 42)
+
+	if r1, _, err := b.Call("wkeVersion"); err != nil || r1 != 42 {
+		t.Fatalf("wkeVersion = %d, %v", r1, err)
+	}
+	if r1, _, _ := b.Call("wkeGetURL", 1); r1 != 0 {
+		t.Fatalf("unhandled call returned %d", r1)
+	}
+
+	calls := b.Calls("wkeGetURL")
+	if len(calls) != 1 || len(calls[0].Args) != 1 || calls[0].Args[0] != 1 {
+		t.Fatalf("calls = %v", calls)
+	}
+	if len(b.Calls()) != 2 {
+		t.Fatalf("all calls = %v", b.Calls())
+	}
+
+	b.Reset()
+	if b.Called("wkeVersion") {
+		t.Fatal("Reset did not clear calls")
+	}
+}
+
+func TestFireView(t *testing.T) {
+	b := New()
+
+	var got []uintptr
+	cb := b.NewCallback(func(view, param uintptr, frame uintptr) uintptr {
+		got = append(got, view, param, frame)
+		return 1
+	})
+
+	_, _, _ = b.Call("wkeOnDocumentReady2", 0x10, cb, 0x99)
+	_, _, _ = b.Call("wkeOnDocumentReady2", 0x20, cb, 0x98)
+
+	if !b.HasCallback("wkeOnDocumentReady2") {
+		t.Fatal("callback not registered")
+	}
+
+	results := b.FireView("wkeOnDocumentReady2", 0x10, 7)
+	if len(results) != 1 || results[0] != 1 {
+		t.Fatalf("results = %v", results)
+	}
+	if len(got) != 3 || got[0] != 0x10 || got[1] != 0x99 || got[2] != 7 {
+		t.Fatalf("callback args = %v", got)
+	}
+
+	got = nil
+	if results := b.Fire("wkeOnDocumentReady2", 1, 2, 3); len(results) != 2 || len(got) != 6 {
+		t.Fatalf("Fire results = %v, args = %v", results, got)
+	}
+}
+
+func TestReleased(t *testing.T) {
+	b := New()
+	_ = b.Release()
+
+	if _, _, err := b.Call("wkeVersion"); err == nil {
+		t.Fatal("call after release should fail")
+	}
+}
@@ -0,0 +1,276 @@
+// HAR 1.2 格式（HTTP Archive）的数据结构，字段含义见 http://www.softwareishard.com/blog/har-12-spec/
+//
+// 未知的时间用 -1 表示，大小未知时同样为 -1
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const Version = "1.2"
+
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string   `json:"version"`
+	Creator Creator  `json:"creator"`
+	Browser *Creator `json:"browser,omitempty"`
+	Pages   []Page   `json:"pages,omitempty"`
+	Entries []Entry  `json:"entries"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type Page struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	ID              string      `json:"id"`
+	Title           string      `json:"title"`
+	PageTimings     PageTimings `json:"pageTimings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type PageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+	Comment       string  `json:"comment,omitempty"`
+}
+
+type Entry struct {
+	Pageref         string    `json:"pageref,omitempty"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // 毫秒
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           Cache     `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Connection      string    `json:"connection,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+	Comment     string      `json:"comment,omitempty"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+	Comment     string      `json:"comment,omitempty"`
+	Error       string      `json:"_error,omitempty"` // 请求失败的原因，HAR 的自定义字段
+}
+
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	Comment  string     `json:"comment,omitempty"`
+}
+
+type NameValue struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type PostData struct {
+	MimeType string  `json:"mimeType"`
+	Params   []Param `json:"params"`
+	Text     string  `json:"text"`
+	Comment  string  `json:"comment,omitempty"`
+}
+
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+type Content struct {
+	Size        int64  `json:"size"`
+	Compression int64  `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"` // 为 base64 时 Text 为 base64 编码
+	Comment     string `json:"comment,omitempty"`
+}
+
+type Cache struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+	Comment string  `json:"comment,omitempty"`
+}
+
+func New(creator Creator) *HAR {
+	return &HAR{
+		Log: Log{
+			Version: Version,
+			Creator: creator,
+			Entries: []Entry{},
+		},
+	}
+}
+
+// 读取 HAR 文件
+func Read(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// 写为 JSON
+func (h *HAR) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// 按请求方法和 url 查找记录，skip 为跳过的匹配数，用于同一 url 的多次请求
+func (h *HAR) Find(method, url string, skip int) *Entry {
+	for i := range h.Log.Entries {
+		entry := &h.Log.Entries[i]
+		if !strings.EqualFold(entry.Request.Method, method) || entry.Request.URL != url {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+// 转为 HAR 的 header 列表，按名称排序
+func Headers(header http.Header) []NameValue {
+	result := make([]NameValue, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// 转为 http.Header
+func ToHeader(list []NameValue) http.Header {
+	header := make(http.Header, len(list))
+	for _, nv := range list {
+		header.Add(nv.Name, nv.Value)
+	}
+	return header
+}
+
+// 解析 url 的查询参数
+func QueryString(rawURL string) []NameValue {
+	result := []NameValue{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return result
+	}
+
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		result = append(result, NameValue{Name: name, Value: value})
+	}
+
+	return result
+}
+
+// 设置内容，非文本内容使用 base64 编码
+func (c *Content) SetBody(body []byte) {
+	c.Size = int64(len(body))
+	if isText(c.MimeType, body) && utf8.Valid(body) {
+		c.Text, c.Encoding = string(body), ""
+	} else {
+		c.Text, c.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+}
+
+// 获取内容，按 Encoding 解码
+func (c *Content) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// 是否记录了内容，Size 不为 0 而 Text 为空表示记录时没有保存数据
+func (c *Content) HasBody() bool {
+	return c.Text != "" || c.Size == 0
+}
+
+func isText(mimeType string, body []byte) bool {
+	mimeType = strings.ToLower(mimeType)
+	switch {
+	case strings.HasPrefix(mimeType, "text/"),
+		strings.Contains(mimeType, "json"),
+		strings.Contains(mimeType, "javascript"),
+		strings.Contains(mimeType, "xml"):
+		return true
+	case mimeType == "":
+		return strings.HasPrefix(http.DetectContentType(body), "text/")
+	}
+	return false
+}
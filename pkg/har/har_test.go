@@ -0,0 +1,103 @@
+package har
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	h := New(Creator{Name: "test"})
+	h.Log.Entries = []Entry{
+		{Request: Request{Method: "GET", URL: "http://a.test/"}, Comment: "first"},
+		{Request: Request{Method: "POST", URL: "http://a.test/"}, Comment: "post"},
+		{Request: Request{Method: "GET", URL: "http://a.test/"}, Comment: "second"},
+	}
+
+	if e := h.Find("get", "http://a.test/", 0); e == nil || e.Comment != "first" {
+		t.Fatalf("Find(skip 0) = %+v", e)
+	}
+	if e := h.Find("GET", "http://a.test/", 1); e == nil || e.Comment != "second" {
+		t.Fatalf("Find(skip 1) = %+v", e)
+	}
+	if e := h.Find("GET", "http://a.test/", 2); e != nil {
+		t.Fatalf("Find(skip 2) = %+v", e)
+	}
+	if e := h.Find("POST", "http://a.test/", 0); e == nil || e.Comment != "post" {
+		t.Fatalf("Find(POST) = %+v", e)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	header := http.Header{"X-B": {"2"}, "X-A": {"1", "3"}}
+
+	list := Headers(header)
+	want := []NameValue{{Name: "X-A", Value: "1"}, {Name: "X-A", Value: "3"}, {Name: "X-B", Value: "2"}}
+	if !reflect.DeepEqual(list, want) {
+		t.Fatalf("Headers() = %v", list)
+	}
+
+	if back := ToHeader(list); !reflect.DeepEqual(back, header) {
+		t.Fatalf("ToHeader() = %v", back)
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	got := QueryString("http://a.test/?q=a%20b&empty=&flag")
+	want := []NameValue{{Name: "q", Value: "a b"}, {Name: "empty"}, {Name: "flag"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("QueryString() = %v", got)
+	}
+
+	if got := QueryString("http://a.test/"); len(got) != 0 {
+		t.Fatalf("QueryString(no query) = %v", got)
+	}
+}
+
+func TestContentBody(t *testing.T) {
+	var text Content
+	text.MimeType = "application/json"
+	text.SetBody([]byte(`{"a":1}`))
+	if text.Encoding != "" || text.Text != `{"a":1}` || text.Size != 7 {
+		t.Fatalf("text content = %+v", text)
+	}
+
+	var binary Content
+	binary.MimeType = "image/png"
+	binary.SetBody([]byte{0x89, 'P', 'N', 'G', 0})
+	if binary.Encoding != "base64" {
+		t.Fatalf("binary content = %+v", binary)
+	}
+	if body, err := binary.Body(); err != nil || !bytes.Equal(body, []byte{0x89, 'P', 'N', 'G', 0}) {
+		t.Fatalf("Body() = %v, %v", body, err)
+	}
+
+	if !(&Content{}).HasBody() {
+		t.Fatal("empty content should have a body")
+	}
+	if (&Content{Size: 10}).HasBody() {
+		t.Fatal("content without text should not have a body")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	h := New(Creator{Name: "test", Version: "1"})
+	h.Log.Entries = append(h.Log.Entries, Entry{
+		Request:  Request{Method: "GET", URL: "http://a.test/"},
+		Response: Response{Status: 200, Error: "net::ERR_FAILED"},
+	})
+
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Log.Version != Version || len(read.Log.Entries) != 1 || read.Log.Entries[0].Response.Error != "net::ERR_FAILED" {
+		t.Fatalf("Read() = %+v", read.Log)
+	}
+}
@@ -0,0 +1,318 @@
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	Index         []string          // 目录的默认文档，默认 index.html
+	Fallback      string            // history 模式的回退文件，如 /index.html，页面路由找不到文件时返回，默认空
+	MIMETypes     map[string]string // 自定义扩展名对应的 MIME，如 ".vue": "text/plain"，优先于内置类型
+	Precompressed bool              // 是否使用 .br、.gz 预压缩文件，默认false
+}
+
+// 设置目录的默认文档
+func WithIndex(names ...string) func(*Config) {
+	return func(conf *Config) {
+		conf.Index = names
+	}
+}
+
+// 设置 history 模式（SPA）的回退文件
+func WithFallback(file string) func(*Config) {
+	return func(conf *Config) {
+		conf.Fallback = file
+	}
+}
+
+// 设置扩展名对应的 MIME
+func WithMIMEType(ext, mimeType string) func(*Config) {
+	return func(conf *Config) {
+		if conf.MIMETypes == nil {
+			conf.MIMETypes = make(map[string]string)
+		}
+		conf.MIMETypes[strings.ToLower(ext)] = mimeType
+	}
+}
+
+// 使用 .br、.gz 预压缩文件，如 embed.FS 中只保存 app.js.br、app.js.gz
+//
+// 客户端支持对应的编码时直接返回压缩文件，优先 br。客户端不支持时 .gz 解压后返回；
+// 标准库没有 brotli 解码，只有 .br 文件时返回 406。
+// 通过 miniblink 加载时数据不会被解压，请求中不带 Accept-Encoding，因此需要同时提供 .gz 或原文件
+func WithPrecompressed() func(*Config) {
+	return func(conf *Config) {
+		conf.Precompressed = true
+	}
+}
+
+// 常用的 MIME，不依赖系统注册表（Windows 上 .js 等可能被注册为错误的类型）
+var builtinMIMETypes = map[string]string{
+	".html":  "text/html; charset=utf-8",
+	".htm":   "text/html; charset=utf-8",
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".cjs":   "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".wasm":  "application/wasm",
+	".xml":   "application/xml",
+	".txt":   "text/plain; charset=utf-8",
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".ogg":   "audio/ogg",
+	".mp3":   "audio/mpeg",
+	".wav":   "audio/wav",
+	".pdf":   "application/pdf",
+}
+
+// 按扩展名获取 MIME，先查内置类型，再查系统类型，获取不到时返回空字符串
+func TypeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if mimeType, ok := builtinMIMETypes[ext]; ok {
+		return mimeType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// 预压缩文件的扩展名
+const (
+	brotliExt = ".br"
+	gzipExt   = ".gz"
+)
+
+type fileServer struct {
+	fs   http.FileSystem
+	conf Config
+}
+
+// 创建静态文件的 http.Handler
+//
+//   - 按扩展名设置 Content-Type，获取不到时按内容嗅探
+//   - 目录返回默认文档，不列出目录内容
+//   - Range、ETag、Last-Modified 等由 http.ServeContent 处理，仅对普通的 http 服务有效；
+//     miniblink 无法返回 206、304，通过 Resource 加载时不支持分段和条件请求：请求中去掉 Range 和条件请求头，
+//     响应为完整内容（整个文件读入内存）并带 `Accept-Ranges: none`，大的媒体文件应通过本地 http 服务提供
+func FileServer(fileSystem http.FileSystem, withConfig ...func(*Config)) http.Handler {
+	conf := Config{
+		Index: []string{"index.html"},
+	}
+	for _, set := range withConfig {
+		set(&conf)
+	}
+
+	return &fileServer{fs: fileSystem, conf: conf}
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+
+	if s.serveFile(w, r, name) {
+		return
+	}
+
+	if s.conf.Fallback != "" && isNavigation(r, name) && s.serveFile(w, r, path.Clean("/"+s.conf.Fallback)) {
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// 页面路由：没有扩展名，或者请求的是 html
+func isNavigation(r *http.Request, name string) bool {
+	return path.Ext(name) == "" || strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	name, ok := s.resolve(name)
+	if !ok {
+		return false
+	}
+
+	contentType := s.contentType(name)
+
+	if s.conf.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		accept := r.Header.Get("Accept-Encoding")
+		if acceptsEncoding(accept, "br") && s.serveVariant(w, r, name+brotliExt, contentType, "br") {
+			return true
+		}
+		if acceptsEncoding(accept, "gzip") && s.serveVariant(w, r, name+gzipExt, contentType, "gzip") {
+			return true
+		}
+	}
+
+	if s.serveVariant(w, r, name, contentType, "") {
+		return true
+	}
+
+	if !s.conf.Precompressed {
+		return false
+	}
+
+	// 只有 .gz 文件，解压后返回
+	if s.serveGunzip(w, r, name, contentType) {
+		return true
+	}
+
+	// 只有 .br 文件，无法解压
+	http.Error(w, "only a brotli-compressed variant is available and the client does not accept br", http.StatusNotAcceptable)
+	return true
+}
+
+// 解析出实际的文件名，目录按默认文档查找
+func (s *fileServer) resolve(name string) (string, bool) {
+	info, err := s.stat(name)
+	if err == nil && !info.IsDir() {
+		return name, true
+	}
+
+	if err == nil && info.IsDir() {
+		for _, index := range s.conf.Index {
+			if indexName := path.Join(name, index); s.exists(indexName) {
+				return indexName, true
+			}
+		}
+		return "", false
+	}
+
+	if s.exists(name) {
+		return name, true
+	}
+
+	return "", false
+}
+
+// 文件或其预压缩文件是否存在
+func (s *fileServer) exists(name string) bool {
+	if info, err := s.stat(name); err == nil {
+		return !info.IsDir()
+	}
+
+	if !s.conf.Precompressed {
+		return false
+	}
+
+	for _, ext := range []string{brotliExt, gzipExt} {
+		if info, err := s.stat(name + ext); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fileServer) stat(name string) (fs.FileInfo, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+func (s *fileServer) contentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mimeType, ok := s.conf.MIMETypes[ext]; ok {
+		return mimeType
+	}
+	return TypeByExtension(ext)
+}
+
+func (s *fileServer) serveVariant(w http.ResponseWriter, r *http.Request, name, contentType, contentEncoding string) bool {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	header := w.Header()
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	} else if contentEncoding != "" {
+		// 压缩后的内容无法嗅探
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	if contentEncoding != "" {
+		header.Set("Content-Encoding", contentEncoding)
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), f)
+	return true
+}
+
+func (s *fileServer) serveGunzip(w http.ResponseWriter, r *http.Request, name, contentType string) bool {
+	f, err := s.fs.Open(name + gzipExt)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return false
+	}
+	defer gr.Close()
+
+	data, err := io.ReadAll(gr)
+	if err != nil {
+		return false
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+	return true
+}
+
+func acceptsEncoding(accept, encoding string) bool {
+	for _, item := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		// q=0 表示不接受
+		key, value, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.TrimSpace(key) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err != nil || q > 0
+		}
+		return true
+	}
+	return false
+}
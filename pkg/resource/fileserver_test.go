@@ -0,0 +1,172 @@
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func serve(handler http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func testFS(t *testing.T) fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":       {Data: []byte("<h1>home</h1>")},
+		"app.wasm":         {Data: []byte("\x00asm")},
+		"app.mjs":          {Data: []byte("export {}")},
+		"docs/index.html":  {Data: []byte("docs")},
+		"data.vue":         {Data: []byte("<template/>")},
+		"bundle.js.gz":     {Data: gzipData(t, "console.log(1)")},
+		"style.css":        {Data: []byte("body{}")},
+		"style.css.gz":     {Data: gzipData(t, "body{}")},
+		"only.js.br":       {Data: []byte("brotli")},
+		"video.mp4":        {Data: []byte("0123456789"), ModTime: time.Unix(1700000000, 0)},
+		"assets/empty.txt": {Data: []byte{}},
+	}
+}
+
+func TestFileServerMIMEAndIndex(t *testing.T) {
+	handler := FileServer(http.FS(testFS(t)), WithMIMEType(".vue", "text/plain"))
+
+	cases := []struct {
+		target      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/", http.StatusOK, "text/html; charset=utf-8", "<h1>home</h1>"},
+		{"/docs/", http.StatusOK, "text/html; charset=utf-8", "docs"},
+		{"/docs", http.StatusOK, "text/html; charset=utf-8", "docs"},
+		{"/app.wasm", http.StatusOK, "application/wasm", "\x00asm"},
+		{"/app.mjs", http.StatusOK, "text/javascript; charset=utf-8", "export {}"},
+		{"/data.vue", http.StatusOK, "text/plain", "<template/>"},
+		{"/missing.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, c := range cases {
+		rec := serve(handler, http.MethodGet, c.target, nil)
+		if rec.Code != c.status {
+			t.Errorf("%s status = %d, want %d", c.target, rec.Code, c.status)
+			continue
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != c.contentType {
+			t.Errorf("%s Content-Type = %q, want %q", c.target, got, c.contentType)
+		}
+		if rec.Body.String() != c.body {
+			t.Errorf("%s body = %q, want %q", c.target, rec.Body.String(), c.body)
+		}
+	}
+}
+
+func TestFileServerFallback(t *testing.T) {
+	handler := FileServer(http.FS(testFS(t)), WithFallback("/index.html"))
+
+	if rec := serve(handler, http.MethodGet, "/users/42", nil); rec.Code != http.StatusOK || rec.Body.String() != "<h1>home</h1>" {
+		t.Fatalf("route = %d %q", rec.Code, rec.Body.String())
+	}
+
+	// 缺失的静态资源不回退
+	if rec := serve(handler, http.MethodGet, "/missing.js", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing asset = %d", rec.Code)
+	}
+
+	header := http.Header{"Accept": {"text/html"}}
+	if rec := serve(handler, http.MethodGet, "/page.html", header); rec.Code != http.StatusOK {
+		t.Fatalf("html navigation = %d", rec.Code)
+	}
+}
+
+func TestFileServerPrecompressed(t *testing.T) {
+	handler := FileServer(http.FS(testFS(t)), WithPrecompressed())
+
+	// 不接受 gzip（如通过 miniblink 加载），解压后返回
+	rec := serve(handler, http.MethodGet, "/bundle.js", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "console.log(1)" || rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("gunzip = %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Fatalf("gunzip Content-Type = %q", got)
+	}
+
+	// 接受 gzip 时直接返回压缩文件
+	rec = serve(handler, http.MethodGet, "/style.css", http.Header{"Accept-Encoding": {"br, gzip"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("gzip = %d %v", rec.Code, rec.Header())
+	}
+
+	rec = serve(handler, http.MethodGet, "/style.css", http.Header{"Accept-Encoding": {"gzip;q=0"}})
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != "body{}" {
+		t.Fatalf("gzip;q=0 = %v %q", rec.Header(), rec.Body.String())
+	}
+
+	// 接受 br 时优先返回 .br 文件
+	rec = serve(handler, http.MethodGet, "/only.js", http.Header{"Accept-Encoding": {"gzip, br"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "br" || rec.Body.String() != "brotli" {
+		t.Fatalf("br = %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Fatalf("br Content-Type = %q", got)
+	}
+
+	// 只有 .br 文件且不接受 br（如通过 miniblink 加载），无法解压
+	if rec := serve(handler, http.MethodGet, "/only.js", nil); rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("br only = %d", rec.Code)
+	}
+
+	// 未开启时不使用预压缩文件
+	if rec := serve(FileServer(http.FS(testFS(t))), http.MethodGet, "/bundle.js", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("precompressed disabled = %d", rec.Code)
+	}
+}
+
+func TestFileServerRangeAndMethods(t *testing.T) {
+	handler := FileServer(http.FS(testFS(t)))
+
+	rec := serve(handler, http.MethodGet, "/video.mp4", http.Header{"Range": {"bytes=2-4"}})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "234" {
+		t.Fatalf("range = %d %q", rec.Code, rec.Body.String())
+	}
+
+	lastModified := rec.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("Last-Modified not set")
+	}
+	if rec := serve(handler, http.MethodGet, "/video.mp4", http.Header{"If-Modified-Since": {lastModified}}); rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional = %d", rec.Code)
+	}
+
+	// 与其他 http.Handler 一样不限制请求方法
+	if rec := serve(handler, http.MethodPost, "/assets/empty.txt", nil); rec.Code != http.StatusOK {
+		t.Fatalf("POST = %d", rec.Code)
+	}
+}
@@ -7,13 +7,25 @@ import (
 	"net/http"
 	netUrl "net/url"
 	"os"
+	"strings"
+	"sync"
 )
 
-type Resource map[string]http.FileSystem
+type Resource struct {
+	fileSystems map[string]http.FileSystem
+	servers     map[string]http.Handler // FileServer of fileSystems
+	handlers    map[string]http.Handler
+	schemes     map[string]*scheme
+	locker      sync.RWMutex
+}
 
 func New() *Resource {
-	loader := make(Resource)
-	return &loader
+	return &Resource{
+		fileSystems: make(map[string]http.FileSystem),
+		servers:     make(map[string]http.Handler),
+		handlers:    make(map[string]http.Handler),
+		schemes:     make(map[string]*scheme),
+	}
 }
 
 // Bind fileSystem to domain
@@ -24,76 +36,120 @@ func New() *Resource {
 //   - fs.SubFS
 //   - http.FileSystem
 //   - string of directory (The resource will not embed, you should copy the files to the target build directory)
-func (res *Resource) Bind(domain string, fileSystem interface{}) (err error) {
-	uri, err := netUrl.Parse(domain)
+//
+// withConfig sets serving options of the domain, see FileServer
+func (res *Resource) Bind(domain string, fileSystem interface{}, withConfig ...func(*Config)) (err error) {
+	dm, err := parseDomain(domain)
 	if err != nil {
 		return
 	}
-	dm := uri.Host
-	if uri.Host == "" {
-		dm = uri.Path
-	}
 
+	var hfs http.FileSystem
 	switch v := fileSystem.(type) {
 	case http.FileSystem:
-		(*res)[dm] = v
+		hfs = v
 	case embed.FS:
-		(*res)[dm] = http.FS(v)
+		hfs = http.FS(v)
 	case string:
-		(*res)[dm] = http.FS(os.DirFS(v))
+		hfs = http.FS(os.DirFS(v))
 	case fs.SubFS:
-		(*res)[dm] = http.FS(v)
+		hfs = http.FS(v)
 	case fs.FS:
-		(*res)[dm] = http.FS(v)
+		hfs = http.FS(v)
 	default:
-		err = errors.New("fs type error, only accept: http.FileSystem, embed.FS, fs.FS, fs.SubFS or string of directory")
+		return errors.New("fs type error, only accept: http.FileSystem, embed.FS, fs.FS, fs.SubFS or string of directory")
 	}
 
+	res.locker.Lock()
+	defer res.locker.Unlock()
+
+	res.fileSystems[dm] = hfs
+	res.servers[dm] = FileServer(hfs, withConfig...)
+
 	return
 }
 
+// Handle binds http.Handler to domain, requests of the domain will be served by the handler in process.
+//
+// Handler takes precedence over fileSystem bound to the same domain.
+func (res *Resource) Handle(domain string, handler http.Handler) error {
+	if handler == nil {
+		return errors.New("handler is nil")
+	}
+
+	dm, err := parseDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	res.locker.Lock()
+	defer res.locker.Unlock()
+
+	res.handlers[dm] = handler
+
+	return nil
+}
+
 func (res *Resource) Unbind(domain string) {
-	uri, err := netUrl.Parse(domain)
+	dm, err := parseDomain(domain)
 	if err != nil {
 		return
 	}
-	dm := uri.Host
-	if uri.Host == "" {
-		dm = uri.Path
-	}
-	delete(*res, dm)
+
+	res.locker.Lock()
+	defer res.locker.Unlock()
+
+	delete(res.fileSystems, dm)
+	delete(res.servers, dm)
+	delete(res.handlers, dm)
 }
 
 func (res *Resource) IsExist(domain string) bool {
-	uri, err := netUrl.Parse(domain)
+	dm, err := parseDomain(domain)
 	if err != nil {
 		return false
 	}
-	dm := uri.Host
-	if uri.Host == "" {
-		dm = uri.Path
+
+	res.locker.RLock()
+	defer res.locker.RUnlock()
+
+	if _, exist := res.handlers[dm]; exist {
+		return true
 	}
-	_, exist := (*res)[dm]
+	_, exist := res.fileSystems[dm]
 	return exist
 }
 
-func (res *Resource) GetFile(url string) http.File {
-
-	uri, err := netUrl.Parse(url)
-	if err != nil {
+// GetHandler returns the http.Handler of url, nil if not exist.
+//
+// Handler of registered scheme takes precedence, then handler bound to the domain, then FileServer of bound fileSystem.
+func (res *Resource) GetHandler(url string) http.Handler {
+	uri, ok := res.parseURL(url)
+	if !ok {
 		return nil
 	}
 
-	//只响应http
-	if uri.Scheme != "http" && uri.Scheme != "https" {
-		return nil
+	res.locker.RLock()
+	s, exist := res.schemes[strings.ToLower(uri.Scheme)]
+	res.locker.RUnlock()
+
+	if exist && s.handler != nil {
+		return s.handler
 	}
 
-	dm := uri.Host
-	if uri.Host == "" {
-		dm = uri.Path
+	return res.domainHandler(hostOf(uri))
+}
+
+func (res *Resource) GetFile(url string) http.File {
+	uri, ok := res.parseURL(url)
+	if !ok {
+		return nil
 	}
-	fs, exist := (*res)[dm]
+
+	res.locker.RLock()
+	fs, exist := res.fileSystems[hostOf(uri)]
+	res.locker.RUnlock()
+
 	if !exist {
 		return nil
 	}
@@ -105,3 +161,36 @@ func (res *Resource) GetFile(url string) http.File {
 
 	return f
 }
+
+func (res *Resource) parseURL(url string) (*netUrl.URL, bool) {
+	uri, err := netUrl.Parse(url)
+	if err != nil {
+		return nil, false
+	}
+
+	//只响应http及注册的scheme
+	switch scheme := strings.ToLower(uri.Scheme); scheme {
+	case "http", "https":
+		return uri, true
+	default:
+		res.locker.RLock()
+		_, exist := res.schemes[scheme]
+		res.locker.RUnlock()
+		return uri, exist
+	}
+}
+
+func parseDomain(domain string) (string, error) {
+	uri, err := netUrl.Parse(domain)
+	if err != nil {
+		return "", err
+	}
+	return hostOf(uri), nil
+}
+
+func hostOf(uri *netUrl.URL) string {
+	if uri.Host == "" {
+		return uri.Path
+	}
+	return uri.Host
+}
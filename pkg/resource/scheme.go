@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var schemeRegexp = regexp.MustCompile(`^[a-z][a-z0-9+.\-]*$`)
+
+// schemes handled by the browser itself
+var reservedSchemes = map[string]bool{
+	"http": true, "https": true, "file": true, "ftp": true, "data": true, "blob": true,
+	"about": true, "javascript": true, "ws": true, "wss": true, "mailto": true,
+}
+
+// 自定义 scheme 的配置
+//
+// 没有 secure / standard 选项：miniblink 的导出函数（wke.h）中没有注册 scheme 的接口，
+// 无法把自定义 scheme 加入 Blink 的 SchemeRegistry，设置了也不会生效，因此不提供。
+// 页面的 origin 是否为 secure context、localStorage 能否使用，均以 miniblink 对未知 scheme 的默认处理为准。
+// 需要 secure context 的页面（如 crypto.subtle、Service Worker）请继续使用 Bind 绑定的 http(s) 域名
+type SchemeConfig struct {
+	CORS bool // 允许其它源跨域访问：返回 Access-Control-Allow-* 响应头，并响应 OPTIONS 预检请求，默认false
+}
+
+// 允许其它源跨域访问
+func WithCORS() func(*SchemeConfig) {
+	return func(conf *SchemeConfig) {
+		conf.CORS = true
+	}
+}
+
+type scheme struct {
+	handler http.Handler
+	conf    SchemeConfig
+}
+
+// HandleScheme registers custom url scheme, such as `app://host/path`.
+//
+// All requests of the scheme will be served by handler. If handler is nil, requests are routed by host
+// to the handler or fileSystem bound by Handle / Bind, e.g. `app://local/index.html` serves file of domain `local`.
+func (res *Resource) HandleScheme(name string, handler http.Handler, withConfig ...func(*SchemeConfig)) error {
+	name = strings.ToLower(strings.TrimSuffix(name, "://"))
+	if !schemeRegexp.MatchString(name) {
+		return fmt.Errorf("invalid scheme: %q", name)
+	}
+	if reservedSchemes[name] {
+		return fmt.Errorf("scheme %q is reserved", name)
+	}
+
+	conf := SchemeConfig{}
+	for _, set := range withConfig {
+		set(&conf)
+	}
+
+	s := &scheme{conf: conf}
+
+	if handler != nil || conf.CORS {
+		s.handler = res.schemeHandler(handler, conf)
+	}
+
+	res.locker.Lock()
+	defer res.locker.Unlock()
+
+	res.schemes[name] = s
+
+	return nil
+}
+
+// UnhandleScheme removes the registered scheme.
+func (res *Resource) UnhandleScheme(name string) {
+	name = strings.ToLower(strings.TrimSuffix(name, "://"))
+
+	res.locker.Lock()
+	defer res.locker.Unlock()
+
+	delete(res.schemes, name)
+}
+
+func (res *Resource) schemeHandler(handler http.Handler, conf SchemeConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := handler
+		if h == nil {
+			h = res.domainHandler(hostOf(r.URL))
+		}
+
+		if conf.CORS && serveCORS(w, r) {
+			return
+		}
+
+		if h == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (res *Resource) domainHandler(dm string) http.Handler {
+	res.locker.RLock()
+	defer res.locker.RUnlock()
+
+	if handler, exist := res.handlers[dm]; exist {
+		return handler
+	}
+	if server, exist := res.servers[dm]; exist {
+		return server
+	}
+	return nil
+}
+
+// 设置跨域响应头，预检请求直接响应并返回 true
+func serveCORS(w http.ResponseWriter, r *http.Request) bool {
+	header := w.Header()
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = "*"
+	} else {
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	header.Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		header.Set("Access-Control-Allow-Headers", headers)
+	}
+	header.Set("Access-Control-Max-Age", "86400")
+	w.WriteHeader(http.StatusNoContent)
+
+	return true
+}
@@ -0,0 +1,351 @@
+// 根据 IPC handler 的 GO 类型生成 TypeScript 声明文件（.d.ts）
+//
+// 生成的声明包含每个 channel 的 invoke/invokeWithTimeout/stream/sent 重载，
+// GO 结构体按 json tag 转为 interface，前端引入后即可在编译期检查 channel 名称与参数类型
+//
+//	gen := tsgen.New()
+//	gen.Add(tsgen.Channel{Name: "getUser", Params: []reflect.Type{reflect.TypeOf(0)}, Result: reflect.TypeOf(User{})})
+//	gen.WriteTo(os.Stdout)
+package tsgen
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/epkgs/blink/internal/cast"
+)
+
+const (
+	// 以该 build tag 构建的程序在 KeepRunning 时生成声明并退出，不加载 miniblink DLL，见 cmd/blink-tsgen
+	BuildTag = "blink_tsgen"
+	// 以 BuildTag 构建时，声明的输出路径，正常构建的程序不读取
+	EnvOutput = "BLINK_TSGEN_OUT"
+	// 生成声明时使用的命名空间
+	EnvNamespace = "BLINK_TSGEN_NAMESPACE"
+
+	DefaultNamespace = "blink"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType           = reflect.TypeOf((*error)(nil)).Elem()
+	identifierRegexp    = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	invalidIdentRegexp  = regexp.MustCompile(`[^A-Za-z0-9_$]+`)
+	reservedIdentifiers = map[string]bool{"IPC": true, "IPCStream": true}
+)
+
+// 一个 IPC channel 的签名
+type Channel struct {
+	Name     string
+	Params   []reflect.Type // 调用参数，不含 context.Context 等注入的参数
+	Variadic bool           // 最后一个参数是否为可变参数（类型为切片）
+	Result   reflect.Type   // 返回值类型，nil 表示没有返回值
+	Stream   bool           // 是否为流式 handler，此时 Result 为每条数据的类型，nil 表示任意类型
+}
+
+type Generator struct {
+	Namespace string
+
+	channels map[string]Channel
+
+	names map[reflect.Type]string // 已声明的结构体
+	used  map[string]reflect.Type // 已使用的 interface 名称
+	decls []string
+}
+
+func New() *Generator {
+	return &Generator{
+		Namespace: DefaultNamespace,
+		channels:  make(map[string]Channel),
+	}
+}
+
+// 添加 channel，同名的会被覆盖
+func (g *Generator) Add(channel Channel) *Generator {
+	g.channels[channel.Name] = channel
+	return g
+}
+
+// 从 handler 函数的类型解析 channel 签名
+//
+// skip 判断开头的参数是否为注入参数（如 context.Context），这些参数不占用调用参数；
+// 返回值规则与 IPC.Handle 一致，返回 <-chan T 的 handler 视为流式
+func FromFunc(name string, fnType reflect.Type, skip func(reflect.Type) (skip bool, stream bool)) Channel {
+	ch := Channel{Name: name, Variadic: fnType.IsVariadic()}
+
+	offset := 0
+	for offset < fnType.NumIn() && skip != nil {
+		skipped, stream := skip(fnType.In(offset))
+		if !skipped {
+			break
+		}
+		ch.Stream = ch.Stream || stream
+		offset++
+	}
+
+	for i := offset; i < fnType.NumIn(); i++ {
+		ch.Params = append(ch.Params, fnType.In(i))
+	}
+	if len(ch.Params) == 0 {
+		ch.Variadic = false
+	}
+
+	switch fnType.NumOut() {
+	case 1:
+		if fnType.Out(0) != errorType {
+			ch.Result = fnType.Out(0)
+		}
+	case 2:
+		ch.Result = fnType.Out(0)
+	}
+
+	if ch.Result != nil && ch.Result.Kind() == reflect.Chan && ch.Result.ChanDir()&reflect.RecvDir != 0 {
+		ch.Result = ch.Result.Elem()
+		ch.Stream = true
+	} else if ch.Stream {
+		ch.Result = nil
+	}
+
+	return ch
+}
+
+// 生成声明文件的内容
+func (g *Generator) String() string {
+	var buf bytes.Buffer
+	_, _ = g.WriteTo(&buf)
+	return buf.String()
+}
+
+func (g *Generator) WriteTo(w io.Writer) (int64, error) {
+	g.names = make(map[reflect.Type]string)
+	g.used = make(map[string]reflect.Type)
+	g.decls = nil
+
+	namespace := g.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	names := make([]string, 0, len(g.channels))
+	for name := range g.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var methods []string
+	for _, name := range names {
+		methods = append(methods, g.overloads(g.channels[name])...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by blink-tsgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "declare namespace %s {\n", namespace)
+
+	for _, decl := range g.decls {
+		buf.WriteString(decl)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("    interface IPCStream<T> extends AsyncIterableIterator<T> {\n")
+	buf.WriteString("        cancel(): void;\n")
+	buf.WriteString("    }\n\n")
+
+	buf.WriteString("    interface IPC {\n")
+	for _, method := range methods {
+		buf.WriteString("        ")
+		buf.WriteString(method)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("        handle(channel: string, handler: (...args: any[]) => any, onlyInJS?: boolean): void;\n")
+	buf.WriteString("    }\n")
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(&buf, "interface Window {\n    ipc: %s.IPC;\n}\n\n", namespace)
+	fmt.Fprintf(&buf, "declare var ipc: %s.IPC;\n", namespace)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func (g *Generator) overloads(ch Channel) []string {
+	params := []string{"channel: " + strconv.Quote(ch.Name)}
+	for i, p := range ch.Params {
+		if ch.Variadic && i == len(ch.Params)-1 {
+			params = append(params, fmt.Sprintf("...args: %s", g.tsType(p)))
+			continue
+		}
+		params = append(params, fmt.Sprintf("arg%d: %s", i, g.tsType(p)))
+	}
+
+	result := "void"
+	item := "any"
+	if ch.Result != nil {
+		result = g.tsType(ch.Result)
+		item = result
+	}
+	if ch.Stream {
+		// 非流式调用流式 handler 时，结果为所有数据组成的数组
+		result = arrayOf(item)
+	}
+
+	args := strings.Join(params, ", ")
+	methods := []string{
+		fmt.Sprintf("invoke(%s): Promise<%s>;", args, result),
+		fmt.Sprintf("invokeWithTimeout(timeout: number, %s): Promise<%s>;", args, result),
+		fmt.Sprintf("sent(%s): void;", args),
+	}
+	if ch.Stream {
+		methods = append(methods, fmt.Sprintf("stream(%s): IPCStream<%s>;", args, item))
+	}
+	return methods
+}
+
+// GO 类型转为 TypeScript 类型，与 JSON 序列化及 IPC 二进制传输的结果一致
+func (g *Generator) tsType(t reflect.Type) string {
+	if t == timeType {
+		return "string"
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return "any"
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Ptr:
+		return g.tsType(t.Elem()) + " | null"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "Uint8Array"
+		}
+		return arrayOf(g.tsType(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.tsType(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.inlineStruct(t)
+		}
+		return g.declare(t)
+	}
+
+	return "any"
+}
+
+func arrayOf(item string) string {
+	if strings.ContainsAny(item, " |") {
+		return "(" + item + ")[]"
+	}
+	return item + "[]"
+}
+
+// 声明具名结构体，返回 interface 名称
+func (g *Generator) declare(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := g.interfaceName(t)
+	g.names[t] = name
+	g.used[name] = t
+
+	// 先占位，保证嵌套类型的声明顺序稳定
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+	g.decls[idx] = fmt.Sprintf("    interface %s %s\n", name, g.structBody(t, "    "))
+
+	return name
+}
+
+func (g *Generator) interfaceName(t reflect.Type) string {
+	base := invalidIdentRegexp.ReplaceAllString(t.Name(), "_")
+	base = strings.Trim(base, "_")
+	if base == "" {
+		base = "Anonymous"
+	}
+
+	name := base
+	if _, exist := g.used[name]; exist || reservedIdentifiers[name] {
+		// 不同包的同名结构体，使用包名作为前缀
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[i+1:]
+		}
+		pkg = invalidIdentRegexp.ReplaceAllString(pkg, "_")
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + base
+	}
+	for i := 2; ; i++ {
+		if _, exist := g.used[name]; !exist && !reservedIdentifiers[name] {
+			break
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	return name
+}
+
+func (g *Generator) structBody(t reflect.Type, indent string) string {
+	members := g.members(t)
+	if len(members) == 0 {
+		return "{}"
+	}
+
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	for _, member := range members {
+		fmt.Fprintf(&buf, "%s    %s;\n", indent, member)
+	}
+	buf.WriteString(indent)
+	buf.WriteString("}")
+
+	return buf.String()
+}
+
+// 匿名结构体，声明在同一行
+func (g *Generator) inlineStruct(t reflect.Type) string {
+	members := g.members(t)
+	if len(members) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(members, "; ") + " }"
+}
+
+func (g *Generator) members(t reflect.Type) []string {
+	var members []string
+	for _, f := range cast.JSONFields(t) {
+		name := f.Name
+		if !identifierRegexp.MatchString(name) {
+			name = strconv.Quote(name)
+		}
+		if f.OmitEmpty {
+			name += "?"
+		}
+
+		typ := g.tsType(f.Type)
+		if f.Quoted && (typ == "number" || typ == "boolean") {
+			typ = "string"
+		}
+
+		members = append(members, name+": "+typ)
+	}
+	return members
+}
@@ -0,0 +1,116 @@
+package tsgen
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type User struct {
+	ID       int64             `json:"id,string"`
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty"`
+	Address  *Address          `json:"address"`
+	Tags     []string          `json:"tags"`
+	Avatar   []byte            `json:"avatar"`
+	Extra    map[string]any    `json:"extra"`
+	Created  time.Time         `json:"created"`
+	Inline   struct{ X int }   `json:"inline"`
+	Ignored  string            `json:"-"`
+	Settings map[string]string `json:"my-settings"`
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+func skipContext(t reflect.Type) (bool, bool) {
+	return t == contextType, false
+}
+
+func TestFromFunc(t *testing.T) {
+	ch := FromFunc("getUser", reflect.TypeOf(func(ctx context.Context, id int, names ...string) (*User, error) { return nil, nil }), skipContext)
+	if len(ch.Params) != 2 || ch.Params[0].Kind() != reflect.Int || !ch.Variadic || ch.Stream {
+		t.Fatalf("channel = %+v", ch)
+	}
+	if ch.Result != reflect.TypeOf(&User{}) {
+		t.Fatalf("result = %v", ch.Result)
+	}
+
+	ch = FromFunc("save", reflect.TypeOf(func(u User) error { return nil }), skipContext)
+	if ch.Result != nil || ch.Variadic {
+		t.Fatalf("error only channel = %+v", ch)
+	}
+
+	ch = FromFunc("ticks", reflect.TypeOf(func(ctx context.Context) (<-chan int, error) { return nil, nil }), skipContext)
+	if !ch.Stream || ch.Result.Kind() != reflect.Int || len(ch.Params) != 0 {
+		t.Fatalf("stream channel = %+v", ch)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	gen := New()
+	gen.Namespace = "app"
+	gen.Add(FromFunc("getUser", reflect.TypeOf(func(id int) (User, error) { return User{}, nil }), nil))
+	gen.Add(FromFunc("ticks", reflect.TypeOf(func() <-chan float64 { return nil }), nil))
+	gen.Add(FromFunc("ping", reflect.TypeOf(func() {}), nil))
+
+	out := gen.String()
+
+	for _, want := range []string{
+		"declare namespace app {",
+		"    interface User {",
+		"        id: string;",
+		"        name: string;",
+		"        email?: string;",
+		"        address: Address | null;",
+		"        tags: string[];",
+		"        avatar: Uint8Array;",
+		"        extra: Record<string, any>;",
+		"        created: string;",
+		"        inline: { X: number };",
+		`        "my-settings": Record<string, string>;`,
+		"    interface Address {",
+		`invoke(channel: "getUser", arg0: number): Promise<User>;`,
+		`invokeWithTimeout(timeout: number, channel: "getUser", arg0: number): Promise<User>;`,
+		`sent(channel: "ping"): void;`,
+		`invoke(channel: "ping"): Promise<void>;`,
+		`invoke(channel: "ticks"): Promise<number[]>;`,
+		`stream(channel: "ticks"): IPCStream<number>;`,
+		"declare var ipc: app.IPC;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "Ignored") {
+		t.Errorf("output contains ignored field\n%s", out)
+	}
+
+	// channel 按名称排序，输出稳定
+	if out != gen.String() {
+		t.Error("output is not stable")
+	}
+	if strings.Index(out, `"getUser"`) > strings.Index(out, `"ping"`) {
+		t.Error("channels are not sorted")
+	}
+}
+
+func TestInterfaceNames(t *testing.T) {
+	type IPC struct {
+		A int `json:"a"`
+	}
+
+	gen := New()
+	gen.Add(Channel{Name: "a", Params: []reflect.Type{reflect.TypeOf(IPC{})}})
+
+	out := gen.String()
+	if !strings.Contains(out, "interface TsgenIPC {") || !strings.Contains(out, "arg0: TsgenIPC") {
+		t.Fatalf("reserved name not renamed\n%s", out)
+	}
+}
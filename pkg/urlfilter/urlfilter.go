@@ -0,0 +1,476 @@
+// 请求过滤引擎，用于拦截广告、统计、跟踪等请求
+//
+// 支持以下规则：
+//   - 域名列表：屏蔽域名及其子域名，可读取 hosts 文件格式（0.0.0.0 ads.example.com）
+//   - 通配符：* 匹配任意字符，? 匹配单个字符，可限定资源类型
+//   - Adblock Plus 规则：||、|、^、*、/正则/、@@ 例外规则，以及资源类型、third-party、domain=、match-case 选项，元素隐藏规则（##）与不支持的选项会被跳过
+//
+// 示例：
+//
+//	f := urlfilter.New()
+//	f.AddHosts("doubleclick.net")
+//	f.AddRule("||google-analytics.com^$script,third-party")
+//	rule, blocked := f.Match(urlfilter.Request{URL: url, Type: urlfilter.Script, DocumentURL: pageURL})
+package urlfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	netUrl "net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+type ResourceType int
+
+const (
+	Other ResourceType = iota
+	Document
+	Subdocument
+	Stylesheet
+	Script
+	Image
+	Font
+	Object
+	Media
+	XMLHttpRequest
+	Ping
+	WebSocket
+)
+
+// Adblock Plus 的资源类型选项
+var resourceTypeOptions = map[string]ResourceType{
+	"other":          Other,
+	"document":       Document,
+	"subdocument":    Subdocument,
+	"stylesheet":     Stylesheet,
+	"script":         Script,
+	"image":          Image,
+	"font":           Font,
+	"object":         Object,
+	"media":          Media,
+	"xmlhttprequest": XMLHttpRequest,
+	"ping":           Ping,
+	"websocket":      WebSocket,
+}
+
+// 待匹配的请求
+type Request struct {
+	URL         string
+	Type        ResourceType
+	DocumentURL string // 发起请求的页面，用于 third-party、domain= 判断，为空时视为第一方请求
+}
+
+type rule struct {
+	text string
+
+	pattern    *regexp.Regexp
+	exception  bool
+	types      map[ResourceType]bool // 为空表示不限类型
+	typesNot   map[ResourceType]bool
+	thirdParty int // 0 不限，1 仅第三方，-1 仅第一方
+
+	domains    []string // domain= 中需要匹配的页面域名
+	domainsNot []string // domain= 中排除的页面域名
+}
+
+type Filter struct {
+	locker sync.RWMutex
+
+	hosts map[string]string // 屏蔽的域名 -> 规则文本
+
+	// 按 ||域名 的完整域名索引的规则，其余规则逐条匹配
+	indexed    map[string][]*rule
+	generic    []*rule
+	exceptions []*rule
+}
+
+func New() *Filter {
+	return &Filter{
+		hosts:   make(map[string]string),
+		indexed: make(map[string][]*rule),
+	}
+}
+
+// 屏蔽域名及其子域名
+func (f *Filter) AddHosts(hosts ...string) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, host := range hosts {
+		host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host != "" {
+			f.hosts[host] = host
+		}
+	}
+}
+
+// 读取域名列表，每行一个域名，支持 hosts 文件格式，# 开头为注释
+func (f *Filter) LoadHosts(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// hosts 文件格式：IP 域名...
+		if net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+
+		for _, host := range fields {
+			if host == "localhost" || host == "localhost.localdomain" || host == "broadcasthost" {
+				continue
+			}
+			f.AddHosts(host)
+		}
+	}
+	return scanner.Err()
+}
+
+// 添加通配符规则，* 匹配任意字符，? 匹配单个字符，types 为空时不限资源类型
+func (f *Filter) AddPattern(pattern string, types ...ResourceType) error {
+	re, err := regexp.Compile("(?i)^" + utils.GlobToRegexp(pattern) + "$")
+	if err != nil {
+		return err
+	}
+
+	r := &rule{text: pattern, pattern: re}
+	if len(types) > 0 {
+		r.types = make(map[ResourceType]bool)
+		for _, t := range types {
+			r.types[t] = true
+		}
+	}
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	f.generic = append(f.generic, r)
+	return nil
+}
+
+// 添加一条 Adblock Plus 规则，注释、元素隐藏规则返回 false 和 nil
+func (f *Filter) AddRule(text string) (added bool, err error) {
+	r, index, err := parseRule(text)
+	if err != nil || r == nil {
+		return false, err
+	}
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	switch {
+	case r.exception:
+		f.exceptions = append(f.exceptions, r)
+	case index != "":
+		f.indexed[index] = append(f.indexed[index], r)
+	default:
+		f.generic = append(f.generic, r)
+	}
+
+	return true, nil
+}
+
+// 读取 Adblock Plus 规则文件（如 EasyList），返回添加的规则数；无法解析的规则会被跳过
+func (f *Filter) LoadRules(r io.Reader) (count int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if added, _ := f.AddRule(scanner.Text()); added {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// 判断请求是否需要屏蔽，返回命中的规则
+func (f *Filter) Match(req Request) (matched string, blocked bool) {
+	u, err := netUrl.Parse(req.URL)
+	if err != nil {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	docHost := hostOf(req.DocumentURL)
+
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	matched, blocked = f.matchBlock(req, host, docHost)
+	if !blocked {
+		return "", false
+	}
+
+	for _, r := range f.exceptions {
+		if r.match(req, host, docHost) {
+			return "", false
+		}
+	}
+
+	return matched, true
+}
+
+func (f *Filter) matchBlock(req Request, host, docHost string) (string, bool) {
+	for h := host; h != ""; h = parentDomain(h) {
+		if text, ok := f.hosts[h]; ok {
+			return text, true
+		}
+		for _, r := range f.indexed[h] {
+			if r.match(req, host, docHost) {
+				return r.text, true
+			}
+		}
+	}
+
+	for _, r := range f.generic {
+		if r.match(req, host, docHost) {
+			return r.text, true
+		}
+	}
+
+	return "", false
+}
+
+func (r *rule) match(req Request, host, docHost string) bool {
+	if len(r.types) > 0 && !r.types[req.Type] {
+		return false
+	}
+	if r.typesNot[req.Type] {
+		return false
+	}
+
+	if r.thirdParty != 0 {
+		third := docHost != "" && baseDomain(host) != baseDomain(docHost)
+		if (r.thirdParty > 0) != third {
+			return false
+		}
+	}
+
+	if len(r.domains) > 0 && !matchDomains(docHost, r.domains) {
+		return false
+	}
+	if len(r.domainsNot) > 0 && matchDomains(docHost, r.domainsNot) {
+		return false
+	}
+
+	return r.pattern.MatchString(req.URL)
+}
+
+// 解析 Adblock Plus 规则，index 为 ||域名 规则的完整域名
+func parseRule(text string) (r *rule, index string, err error) {
+	text = strings.TrimSpace(text)
+
+	// 空行、注释、文件头
+	if text == "" || strings.HasPrefix(text, "!") || strings.HasPrefix(text, "[") {
+		return nil, "", nil
+	}
+
+	// 元素隐藏规则
+	if strings.Contains(text, "##") || strings.Contains(text, "#@#") || strings.Contains(text, "#?#") || strings.Contains(text, "#$#") {
+		return nil, "", nil
+	}
+
+	r = &rule{text: text}
+
+	body := text
+	if strings.HasPrefix(body, "@@") {
+		r.exception = true
+		body = body[2:]
+	}
+
+	matchCase := false
+
+	// 选项，正则规则中的 $ 不是选项分隔符
+	if i := strings.LastIndex(body, "$"); i >= 0 && !(strings.HasPrefix(body, "/") && strings.HasSuffix(body, "/")) {
+		options := body[i+1:]
+		body = body[:i]
+
+		if matchCase, err = r.parseOptions(options); err != nil {
+			return nil, "", fmt.Errorf("rule %q: %w", text, err)
+		}
+	}
+
+	flags := "(?i)"
+	if matchCase {
+		flags = ""
+	}
+
+	var expr string
+	if len(body) > 2 && strings.HasPrefix(body, "/") && strings.HasSuffix(body, "/") {
+		expr = body[1 : len(body)-1]
+	} else {
+		expr, index = patternToRegexp(body)
+	}
+
+	if r.pattern, err = regexp.Compile(flags + expr); err != nil {
+		return nil, "", fmt.Errorf("rule %q: %w", text, err)
+	}
+
+	return r, index, nil
+}
+
+func (r *rule) parseOptions(options string) (matchCase bool, err error) {
+	for _, option := range strings.Split(options, ",") {
+		option = strings.ToLower(strings.TrimSpace(option))
+		name, value, _ := strings.Cut(option, "=")
+
+		negate := strings.HasPrefix(name, "~")
+		name = strings.TrimPrefix(name, "~")
+
+		if t, ok := resourceTypeOptions[name]; ok {
+			if negate {
+				if r.typesNot == nil {
+					r.typesNot = make(map[ResourceType]bool)
+				}
+				r.typesNot[t] = true
+			} else {
+				if r.types == nil {
+					r.types = make(map[ResourceType]bool)
+				}
+				r.types[t] = true
+			}
+			continue
+		}
+
+		switch name {
+		case "third-party", "3p":
+			r.thirdParty = 1
+			if negate {
+				r.thirdParty = -1
+			}
+		case "first-party", "1p":
+			r.thirdParty = -1
+			if negate {
+				r.thirdParty = 1
+			}
+		case "domain":
+			for _, d := range strings.Split(value, "|") {
+				if strings.HasPrefix(d, "~") {
+					r.domainsNot = append(r.domainsNot, strings.TrimPrefix(d, "~"))
+				} else if d != "" {
+					r.domains = append(r.domains, d)
+				}
+			}
+		case "match-case":
+			matchCase = true
+		case "xhr":
+			r.types = addType(r.types, XMLHttpRequest)
+		case "css":
+			r.types = addType(r.types, Stylesheet)
+		case "frame":
+			r.types = addType(r.types, Subdocument)
+		default:
+			return false, fmt.Errorf("unsupported option %q", option)
+		}
+	}
+	return matchCase, nil
+}
+
+func addType(types map[ResourceType]bool, t ResourceType) map[ResourceType]bool {
+	if types == nil {
+		types = make(map[ResourceType]bool)
+	}
+	types[t] = true
+	return types
+}
+
+// 将 Adblock Plus 的 url 规则转为正则，||域名 规则的域名完整时同时返回域名用于索引
+func patternToRegexp(pattern string) (expr, index string) {
+	var sb strings.Builder
+
+	switch {
+	case strings.HasPrefix(pattern, "||"):
+		pattern = pattern[2:]
+		sb.WriteString(`^[a-z][a-z0-9+.\-]*:/+(?:[^/?#]*\.)?`)
+
+		// 只有完整的域名才能索引，如 ||example.com^、||example.com/ads；
+		// ||ads.example、||example.com* 等还可以匹配 ads.example.org、example.community，按普通规则逐条匹配
+		if end := strings.IndexAny(pattern, "^/*|:?$"); end > 0 && strings.IndexByte("^/|:?", pattern[end]) >= 0 {
+			index = strings.ToLower(pattern[:end])
+		}
+	case strings.HasPrefix(pattern, "|"):
+		pattern = pattern[1:]
+		sb.WriteString("^")
+	}
+
+	anchorEnd := false
+	if strings.HasSuffix(pattern, "|") {
+		pattern = pattern[:len(pattern)-1]
+		anchorEnd = true
+	}
+
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '^':
+			sb.WriteString(`(?:[^\w\-.%]|$)`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if anchorEnd {
+		sb.WriteString("$")
+	}
+
+	return sb.String(), index
+}
+
+func hostOf(url string) string {
+	if url == "" {
+		return ""
+	}
+	u, err := netUrl.Parse(url)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func parentDomain(host string) string {
+	_, parent, ok := strings.Cut(host, ".")
+	if !ok {
+		return ""
+	}
+	return parent
+}
+
+// 页面域名是否为 domains 中的域名或其子域名
+func matchDomains(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// 国家域名下常见的二级后缀，如 com.cn、co.uk
+var secondLevelSuffixes = map[string]bool{
+	"com": true, "net": true, "org": true, "gov": true, "edu": true, "co": true, "ac": true,
+}
+
+// 粗略的主域名，不使用公共后缀列表：一般取最后两段，国家域名下的 com.cn、co.uk 等取最后三段
+func baseDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	parts := strings.Split(host, ".")
+	n := 2
+	if len(parts) > 2 && len(parts[len(parts)-1]) == 2 && secondLevelSuffixes[parts[len(parts)-2]] {
+		n = 3
+	}
+	if len(parts) <= n {
+		return host
+	}
+	return strings.Join(parts[len(parts)-n:], ".")
+}
@@ -0,0 +1,136 @@
+package urlfilter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHosts(t *testing.T) {
+	f := New()
+	if err := f.LoadHosts(strings.NewReader("# ads\n0.0.0.0 ads.test\n127.0.0.1 localhost\ntracker.test\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"http://ads.test/a.js":        true,
+		"https://cdn.ads.test/a.js":   true,
+		"http://tracker.test/":        true,
+		"http://localhost/":           false,
+		"http://notads.test/":         false,
+		"http://ads.test.example.com": false,
+	}
+	for url, want := range cases {
+		if _, blocked := f.Match(Request{URL: url}); blocked != want {
+			t.Errorf("Match(%s) = %v, want %v", url, blocked, want)
+		}
+	}
+}
+
+func TestDomainAnchor(t *testing.T) {
+	cases := []struct {
+		rule  string
+		url   string
+		block bool
+	}{
+		{"||example.com^", "http://example.com/", true},
+		{"||example.com^", "https://sub.example.com/x", true},
+		{"||example.com^", "http://example.community/", false},
+		{"||example.com/ads", "http://www.example.com/ads/1.js", true},
+		{"||example.com/ads", "http://example.com/news", false},
+		{"||example.com:8080^", "http://example.com:8080/", true},
+		// 不完整的域名不能按域名索引
+		{"||ads.example", "http://ads.example.org/", true},
+		{"||ads.example", "http://ads.example.com/a.js", true},
+		{"||example.com*/ads", "http://example.community/ads", true},
+		{"||example.com*/ads", "http://example.com/news", false},
+	}
+
+	for _, c := range cases {
+		f := New()
+		if added, err := f.AddRule(c.rule); !added || err != nil {
+			t.Fatalf("AddRule(%s) = %v, %v", c.rule, added, err)
+		}
+		if _, blocked := f.Match(Request{URL: c.url}); blocked != c.block {
+			t.Errorf("%s Match(%s) = %v, want %v", c.rule, c.url, blocked, c.block)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	cases := map[string]string{
+		"||example.com^":      "example.com",
+		"||Example.com/path":  "example.com",
+		"||example.com:443^":  "example.com",
+		"||example.com|":      "example.com",
+		"||example.com":       "",
+		"||ads.example":       "",
+		"||example.com*/ads":  "",
+		"|http://example.com": "",
+		"/banner/*":           "",
+	}
+
+	for text, want := range cases {
+		_, index, err := parseRule(text)
+		if err != nil {
+			t.Fatalf("parseRule(%s): %v", text, err)
+		}
+		if index != want {
+			t.Errorf("parseRule(%s) index = %q, want %q", text, index, want)
+		}
+	}
+}
+
+func TestOptionsAndExceptions(t *testing.T) {
+	f := New()
+	count, err := f.LoadRules(strings.NewReader(`[Adblock Plus 2.0]
+! comment
+example.com##.ad
+||ads.test^$script,third-party
+/banner/*$image,domain=news.test|~sport.news.test
+@@||ads.test/allowed.js
+/track[0-9]+/
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Fatalf("LoadRules count = %d, want 4", count)
+	}
+
+	cases := []struct {
+		req   Request
+		block bool
+	}{
+		{Request{URL: "http://ads.test/a.js", Type: Script, DocumentURL: "http://site.test/"}, true},
+		{Request{URL: "http://ads.test/a.js", Type: Image, DocumentURL: "http://site.test/"}, false},
+		{Request{URL: "http://ads.test/a.js", Type: Script, DocumentURL: "http://www.ads.test/"}, false},
+		{Request{URL: "http://ads.test/allowed.js", Type: Script, DocumentURL: "http://site.test/"}, false},
+		{Request{URL: "http://cdn.test/banner/1.png", Type: Image, DocumentURL: "http://news.test/"}, true},
+		{Request{URL: "http://cdn.test/banner/1.png", Type: Image, DocumentURL: "http://sport.news.test/"}, false},
+		{Request{URL: "http://cdn.test/banner/1.png", Type: Image, DocumentURL: "http://other.test/"}, false},
+		{Request{URL: "http://cdn.test/track42"}, true},
+	}
+	for _, c := range cases {
+		if _, blocked := f.Match(c.req); blocked != c.block {
+			t.Errorf("Match(%+v) = %v, want %v", c.req, blocked, c.block)
+		}
+	}
+
+	if _, err := f.AddRule("||x.test^$unknown-option"); err == nil {
+		t.Error("unsupported option should fail")
+	}
+}
+
+func TestPattern(t *testing.T) {
+	f := New()
+	if err := f.AddPattern("*://*.cdn.test/*.gif", Image); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, blocked := f.Match(Request{URL: "http://img.cdn.test/a.gif", Type: Image}); !blocked {
+		t.Error("pattern did not match")
+	}
+	if _, blocked := f.Match(Request{URL: "http://img.cdn.test/a.gif", Type: Script}); blocked {
+		t.Error("pattern matched another resource type")
+	}
+}
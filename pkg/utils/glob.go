@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 通配符转为正则，* 匹配任意字符，? 匹配单个字符，不包含 ^ $ 锚点
+func GlobToRegexp(glob string) string {
+	var sb strings.Builder
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
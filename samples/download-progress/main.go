@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	netUrl "net/url"
+	"os"
+	"sync"
+	"time"
+
+	blink "github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/downloader"
+)
+
+//go:embed static
+var static embed.FS
+
+type Progress struct {
+	Downloaded uint64 `json:"downloaded"`
+	Total      uint64 `json:"total"` // 为 0 表示无法获取文件大小
+}
+
+func main() {
+	app := blink.NewApp()
+	defer app.Exit()
+
+	fileURL := runFileServer()
+
+	res, _ := fs.Sub(static, "static")
+	app.Resource.Bind("local", res) // 将内嵌文件夹绑定到 FileSystem
+
+	// 流式 handler：JS 端使用 ipc.stream 逐个接收下载进度，最后一条数据为下载后的文件路径
+	// 页面调用 cancel() 或 break 时 w.Context() 随之取消，下载也会中止
+	app.IPC.Handle("download", func(w *blink.StreamWriter, url string) (string, error) {
+		latest := make(chan Progress, 1)
+		done := make(chan struct{})
+
+		// 页面消费慢时 Write 会阻塞，只保留最新的进度，不阻塞下载线程
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case p := <-latest:
+					if err := w.Write(p); err != nil {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		d := downloader.NewWithContext(w.Context(), func(c *downloader.Config) {
+			c.Dir = os.TempDir()
+			c.OverwriteFile = true
+			c.OnProgress = func(job *downloader.Job, downloaded, total uint64) {
+				p := Progress{Downloaded: downloaded, Total: total}
+				for {
+					select {
+					case latest <- p:
+						return
+					default:
+					}
+					select {
+					case <-latest: // 丢弃未发送的旧进度
+					default:
+					}
+				}
+			}
+		})
+
+		file, err := d.Download(url)
+
+		close(done)
+		wg.Wait()
+
+		return file, err
+	})
+
+	view := app.CreateWebWindowPopup(func(c *blink.WebWindowConfig) {
+		c.W = 600
+		c.H = 300
+	})
+
+	view.Window.MoveToCenter()
+
+	view.LoadURL("http://local/index.html?url=" + netUrl.QueryEscape(fileURL))
+
+	view.ShowWindow()
+
+	view.OnDestroy(func() {
+		os.Exit(0)
+	})
+
+	app.KeepRunning()
+}
+
+// 本地文件服务，限速输出，便于观察进度
+func runFileServer() string {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4*1024*1024) // 64MB
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	go func() {
+		_ = http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(slowWriter{w}, r, "big.bin", time.Now(), bytes.NewReader(content))
+		}))
+	}()
+
+	return fmt.Sprintf("http://%s/big.bin", ln.Addr())
+}
+
+type slowWriter struct {
+	http.ResponseWriter
+}
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(10 * time.Millisecond)
+	return w.ResponseWriter.Write(p)
+}
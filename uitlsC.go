@@ -4,11 +4,10 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"strings"
 	"syscall"
 	"unicode/utf16"
 	"unsafe"
-
-	"golang.org/x/sys/windows"
 )
 
 type (
@@ -58,7 +57,7 @@ type (
 )
 
 func StringToPtr(s string) uintptr {
-	p, err := windows.BytePtrFromString(s)
+	p, err := syscall.BytePtrFromString(s)
 	if err != nil {
 		return 0
 	}
@@ -67,11 +66,14 @@ func StringToPtr(s string) uintptr {
 }
 
 func PtrToString(p uintptr) string {
-	return windows.BytePtrToString(AssertType[uint8](p))
+	if p == 0 {
+		return ""
+	}
+	return string(unsafe.Slice(AssertType[uint8](p), strlen[uint8](p)))
 }
 
 func StringToChar(s string) []Char {
-	bytes, err := windows.ByteSliceFromString(s)
+	bytes, err := syscall.ByteSliceFromString(s)
 	if err != nil {
 		return nil
 	}
@@ -79,7 +81,7 @@ func StringToChar(s string) []Char {
 }
 
 func StringToWCharPtr(s string) uintptr {
-	p, err := windows.UTF16PtrFromString(s)
+	p, err := utf16PtrFromString(s)
 	if err != nil {
 		return 0
 	}
@@ -87,7 +89,7 @@ func StringToWCharPtr(s string) uintptr {
 }
 
 func StringToWcharU16Ptr(s string) *uint16 {
-	p, err := windows.UTF16PtrFromString(s)
+	p, err := utf16PtrFromString(s)
 	if err != nil {
 		*p = 0
 	}
@@ -99,12 +101,28 @@ func StringToU16Arr(s string) []uint16 {
 }
 
 func PtrWCharToString(p uintptr) string {
-	return windows.UTF16PtrToString(AssertType[uint16](p))
+	if p == 0 {
+		return ""
+	}
+	return string(utf16.Decode(unsafe.Slice(AssertType[uint16](p), strlen[uint16](p))))
+}
+
+// 以 0 结尾的 C 字符串的长度
+func strlen[T uint8 | uint16](p uintptr) int {
+	n := 0
+	for ptr := uintptrToPointer(p); *(*T)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, unsafe.Sizeof(T(0)))
+	}
+	return n
 }
 
-// callback = func(args ...uintptr) uintptr
-func CallbackToPtr(callback interface{}) uintptr {
-	return syscall.NewCallbackCDecl(callback)
+// 同 windows.UTF16PtrFromString，字符串中不能含有 0
+func utf16PtrFromString(s string) (*uint16, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return nil, syscall.EINVAL
+	}
+	a := append(utf16.Encode([]rune(s)), 0)
+	return &a[0], nil
 }
 
 func BoolToPtr(b bool) uintptr {
@@ -121,7 +139,14 @@ func PtrToBool(p uintptr) bool {
 }
 
 func AssertType[T interface{}](ptr uintptr) *T {
-	return (*T)(unsafe.Pointer(ptr))
+	return (*T)(uintptrToPointer(ptr))
+}
+
+// 将 DLL 返回的地址转为指针
+//
+// 直接 unsafe.Pointer(p) 在 -race（checkptr）下，指向 GO 内存（如测试中 fakebackend 传入的字符串）时会被判定为非法的指针运算
+func uintptrToPointer(p uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&p))
 }
 
 type KnownCType interface {
@@ -181,9 +206,7 @@ func CopyBytes(src uintptr, n int) []byte {
 	}
 
 	byts := make([]uint8, n)
-	for i := 0; i < n; i++ {
-		byts[i] = *(*uint8)(unsafe.Pointer(src + uintptr(i)))
-	}
+	copy(byts, unsafe.Slice((*uint8)(uintptrToPointer(src)), n))
 
 	return byts
 }
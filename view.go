@@ -1,6 +1,7 @@
 package blink
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/chebyrash/promise"
 	"github.com/epkgs/blink/internal/log"
+	"github.com/epkgs/blink/pkg/urlfilter"
 	"github.com/epkgs/blink/pkg/utils"
 )
 
@@ -22,44 +24,136 @@ type OnClosingCallback func() bool // 返回 false 拒绝关闭窗口
 type OnDestroyCallback func()
 type OnLoadUrlBeginCallback func(url string, job WkeNetJob) bool
 type OnLoadUrlEndCallback func(url string, job WkeNetJob, buf []byte)
+type OnRequestCallback func(req *Request) bool // 返回 true 则中断、阻止后面的网络请求
+type OnResponseCallback func(resp *Response)
+type OnLoadUrlFinishCallback func(url string, job WkeNetJob, length int)
+type OnLoadUrlFailCallback func(url string, job WkeNetJob)
+type OnBlockedCallback func(req *Request, rule string)
 type OnDocumentReadyCallback func(frame WkeWebFrameHandle)
 type OnDidCreateScriptContextCallback func(frame WkeWebFrameHandle, context uintptr, exGroup, worldId int)
 type OnWillReleaseScriptContextCallback func(frameId WkeWebFrameHandle, context uintptr, worldId int)
 type OnTitleChangedCallback func(title string)
 type OnDownloadCallback func(url string)
 type OnOtherLoadCallback func(loadType WkeOtherLoadType, info *WkeTempCallbackInfo)
+type OnNavigationCallback func(navigationType WkeNavigationType, url string) bool // 返回 false 取消导航
+type OnURLChangedCallback func(frame WkeWebFrameHandle, url string)
+type OnLoadingFinishCallback func(url string, result WkeLoadingResult, failedReason string)
+type OnFrameCallback func(frame *Frame)
 
+// 事件的回调列表，按加入的顺序执行
 type bindEvent[T any] struct {
-	Callbacks map[string]T
-	Register  sync.Once
+	Register sync.Once
+
+	locker    sync.RWMutex
+	keys      []string
+	callbacks map[string]T
 }
 
 func newBindEvent[T any]() *bindEvent[T] {
-	return &bindEvent[T]{Callbacks: make(map[string]T)}
+	return &bindEvent[T]{callbacks: make(map[string]T)}
+}
+
+// 加入 callback，返回移除该 callback 的函数
+func (e *bindEvent[T]) Add(callback T) (remove func()) {
+	key := utils.RandString(10)
+	e.Set(key, callback)
+
+	return func() {
+		e.Delete(key)
+	}
+}
+
+// 按 key 加入 callback，key 已存在时替换，保持原来的顺序
+func (e *bindEvent[T]) Set(key string, callback T) {
+	e.locker.Lock()
+	defer e.locker.Unlock()
+
+	if _, exist := e.callbacks[key]; !exist {
+		e.keys = append(e.keys, key)
+	}
+	e.callbacks[key] = callback
+}
+
+func (e *bindEvent[T]) Get(key string) (callback T, exist bool) {
+	e.locker.RLock()
+	defer e.locker.RUnlock()
+
+	callback, exist = e.callbacks[key]
+	return
+}
+
+func (e *bindEvent[T]) Delete(key string) {
+	e.locker.Lock()
+	defer e.locker.Unlock()
+
+	if _, exist := e.callbacks[key]; !exist {
+		return
+	}
+	delete(e.callbacks, key)
+
+	for i, k := range e.keys {
+		if k == key {
+			e.keys = append(e.keys[:i:i], e.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// 按加入顺序返回当前的 callback，执行期间加入或移除 callback 不影响本次执行
+func (e *bindEvent[T]) Callbacks() []T {
+	e.locker.RLock()
+	defer e.locker.RUnlock()
+
+	callbacks := make([]T, 0, len(e.keys))
+	for _, key := range e.keys {
+		callbacks = append(callbacks, e.callbacks[key])
+	}
+	return callbacks
 }
 
 type View struct {
 	Hwnd     WkeHandle
 	Window   *Window
 	DevTools *View
+	Filter   *urlfilter.Filter // 仅作用于当前 view 的请求过滤规则，Blink.Filter 作用于所有 view
 
 	mb     *Blink
 	parent *View
+	ipc    *ViewIPC
 
 	_didCreateScriptContext bool // 标记是否已经创建了脚本上下文
 
+	zoomFactor float32
+
 	_onDomEvent                         *bindEvent[OnDomEventCallback]
 	_onConsole                          *bindEvent[OnConsoleCallback]
 	_onClosing                          *bindEvent[OnClosingCallback]
 	_onDestroy                          *bindEvent[OnDestroyCallback]
 	_onLoadUrlBegin                     *bindEvent[OnLoadUrlBeginCallback]
 	_onLoadUrlEnd                       *bindEvent[OnLoadUrlEndCallback]
+	_onResponse                         *bindEvent[OnResponseCallback]
+	_onLoadUrlFinish                    *bindEvent[OnLoadUrlFinishCallback]
+	_onLoadUrlFail                      *bindEvent[OnLoadUrlFailCallback]
 	_onDocumentReady                    *bindEvent[OnDocumentReadyCallback]
 	_onTitleChanged                     *bindEvent[OnTitleChangedCallback]
 	_onDownload                         *bindEvent[OnDownloadCallback]
 	_onDidCreateScriptContext           *bindEvent[OnDidCreateScriptContextCallback]
 	_onWillReleaseScriptContextCallback *bindEvent[OnWillReleaseScriptContextCallback]
 	_onOtherLoad                        *bindEvent[OnOtherLoadCallback]
+	_onBlocked                          *bindEvent[OnBlockedCallback]
+	_onNavigation                       *bindEvent[OnNavigationCallback]
+	_onURLChanged                       *bindEvent[OnURLChangedCallback]
+	_onLoadingFinish                    *bindEvent[OnLoadingFinishCallback]
+	_onFrameAttached                    *bindEvent[OnFrameCallback]
+	_onFrameDetached                    *bindEvent[OnFrameCallback]
+	_netObservers                       *bindEvent[*netObserver]
+
+	_netJobs     netJobTracker
+	_jobInfos    netJobInfos
+	_loads       loadTracker
+	_filterStats filterStats
+	_rewriters   rewriters // RewriteResponse 的处理链，需要保持顺序
+	_frames      frameTree
 }
 
 func NewView(mb *Blink, hwnd WkeHandle, windowType WkeWindowType, parent ...*View) *View {
@@ -81,23 +175,45 @@ func NewView(mb *Blink, hwnd WkeHandle, windowType WkeWindowType, parent ...*Vie
 		_onDestroy:                          newBindEvent[OnDestroyCallback](),
 		_onLoadUrlBegin:                     newBindEvent[OnLoadUrlBeginCallback](),
 		_onLoadUrlEnd:                       newBindEvent[OnLoadUrlEndCallback](),
+		_onResponse:                         newBindEvent[OnResponseCallback](),
+		_onLoadUrlFinish:                    newBindEvent[OnLoadUrlFinishCallback](),
+		_onLoadUrlFail:                      newBindEvent[OnLoadUrlFailCallback](),
 		_onDocumentReady:                    newBindEvent[OnDocumentReadyCallback](),
 		_onTitleChanged:                     newBindEvent[OnTitleChangedCallback](),
 		_onDownload:                         newBindEvent[OnDownloadCallback](),
 		_onDidCreateScriptContext:           newBindEvent[OnDidCreateScriptContextCallback](),
 		_onWillReleaseScriptContextCallback: newBindEvent[OnWillReleaseScriptContextCallback](),
 		_onOtherLoad:                        newBindEvent[OnOtherLoadCallback](),
+		_onBlocked:                          newBindEvent[OnBlockedCallback](),
+		_onNavigation:                       newBindEvent[OnNavigationCallback](),
+		_onURLChanged:                       newBindEvent[OnURLChangedCallback](),
+		_onLoadingFinish:                    newBindEvent[OnLoadingFinishCallback](),
+		_onFrameAttached:                    newBindEvent[OnFrameCallback](),
+		_onFrameDetached:                    newBindEvent[OnFrameCallback](),
+		_netObservers:                       newBindEvent[*netObserver](),
+
+		Filter: urlfilter.New(),
 	}
 
 	view.Window = newWindow(mb, view, windowType)
 
+	view.ipc = newViewIPC(mb.IPC, view)
+
 	view.SetLocalStorageFullPath(view.mb.GetStoragePath())
 	view.SetCookieJarFullPath(view.mb.GetCookieFileABS())
+	view.applySettings(mb.viewSettings)
 
+	// 先于其他回调注册，保证按注册顺序执行时最先执行
+	view.trackNetJobs()
+	view.trackLoads()
+
+	view.registerFilter()
 	view.registerFileSystem()
 
 	view.injectBootScripts()
 	view.watchScriptContextState()
+	view.watchFrames()
+	view.watchEvals()
 	view.bindDomEvents() // 绑定一些DOM事件
 
 	view.addToPool()
@@ -171,7 +287,11 @@ func (v *View) DestroyWindow() {
 }
 
 func (v *View) Reload() bool {
+	v._loads.start()
 	r, _, _ := v.mb.CallFunc("wkeReload", uintptr(v.Hwnd))
+	if r == 0 {
+		v._loads.abort()
+	}
 	return r != 0
 }
 
@@ -180,6 +300,7 @@ func (v *View) ForceReload() {
 }
 
 func (v *View) LoadURL(url string) {
+	v._loads.start()
 	_, _, _ = v.mb.CallFunc("wkeLoadURL", uintptr(v.Hwnd), StringToPtr(url))
 }
 
@@ -188,6 +309,11 @@ func (v *View) GetURL() string {
 	return PtrToString(r)
 }
 
+func (v *View) GetTitle() string {
+	r, _, _ := v.mb.CallFunc("wkeGetTitle", uintptr(v.Hwnd))
+	return PtrToString(r)
+}
+
 // 设置local storage的全路径。如“c:\mb\LocalStorage\”
 // 注意：这个接口只能接受目录。
 func (v *View) SetLocalStorageFullPath(path string) {
@@ -208,32 +334,6 @@ func (v *View) Resize(width, height int32) {
 	_, _, _ = v.mb.CallFunc("wkeResize", uintptr(v.Hwnd), uintptr(width), uintptr(height))
 }
 
-func (v *View) registerFileSystem() {
-	v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
-
-		f := v.mb.Resource.GetFile(url)
-
-		// 找不到文件
-		if f == nil {
-			return false
-		}
-
-		defer f.Close()
-
-		byt, err := io.ReadAll(f)
-		// 读取文件错误
-		if err != nil {
-			return false
-		}
-
-		v.mb.NetSetData(job, byt)
-
-		// 找到并读取正常，返回 true 取消后继的网络请求
-		return true
-
-	})
-}
-
 // 可以添加多个 callback，将按照加入顺序依次执行
 //
 // callback 返回 false 拒绝关闭窗口
@@ -242,23 +342,17 @@ func (v *View) OnClosing(callback OnClosingCallback) (stop func()) {
 	v._onClosing.Register.Do(func() {
 		var handler WkeWindowClosingCallback = func(view WkeHandle, param uintptr) (boolRes uintptr) {
 			log.Debug("Trigger view.OnClosing")
-			for _, callback := range v._onClosing.Callbacks {
+			for _, callback := range v._onClosing.Callbacks() {
 				if ok := callback(); !ok {
 					return BoolToPtr(false)
 				}
 			}
 			return BoolToPtr(true)
 		}
-		_, _, _ = v.mb.CallFunc("wkeOnWindowClosing", uintptr(v.Hwnd), CallbackToPtr(handler), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnWindowClosing", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
 	})
 
-	key := utils.RandString(10)
-
-	v._onClosing.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onClosing.Callbacks, key)
-	}
+	return v._onClosing.Add(callback)
 }
 
 // 可以添加多个 callback，将按照加入顺序依次执行
@@ -267,49 +361,46 @@ func (v *View) OnDestroy(callback OnDestroyCallback) (stop func()) {
 	v._onDestroy.Register.Do(func() {
 		var handler WkeWindowDestroyCallback = func(view WkeHandle, param uintptr) (voidRes uintptr) {
 			log.Debug("Trigger view.OnDestroy")
-			for _, callback := range v._onDestroy.Callbacks {
+			for _, callback := range v._onDestroy.Callbacks() {
 				callback()
 			}
 			return
 		}
-		_, _, _ = v.mb.CallFunc("wkeOnWindowDestroy", uintptr(v.Hwnd), CallbackToPtr(handler), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnWindowDestroy", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
 	})
 
-	key := utils.RandString(10)
-
-	v._onDestroy.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onDestroy.Callbacks, key)
-	}
+	return v._onDestroy.Add(callback)
 }
 
 // callback 返回 true 则中断、阻止后面的网络请求
 func (v *View) OnLoadUrlBegin(callback OnLoadUrlBeginCallback) (stop func()) {
+	v.registerLoadUrlBegin()
+
+	return v._onLoadUrlBegin.Add(callback)
+}
 
+func (v *View) registerLoadUrlBegin() {
 	v._onLoadUrlBegin.Register.Do(func() {
 		var handler = func(view, param, url, job uintptr) (boolPtr uintptr) {
 			urlPtr := PtrToString(url)
 			jobPtr := WkeNetJob(job)
-			for _, callback := range v._onLoadUrlBegin.Callbacks {
+
+			v.beginNetJob(urlPtr, jobPtr)
+
+			for _, callback := range v._onLoadUrlBegin.Callbacks() {
 				// 返回 true 则中断、阻止后面的网络请求
 				if callback(urlPtr, jobPtr) {
+					if v._netJobs.handled(jobPtr) {
+						v.endNetJob(jobPtr)
+					}
 					return 1 // 返回 true 的 uintptr
 				}
 			}
 			return 0 // 返回 false 的 uintptr
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlBegin", uintptr(v.Hwnd), CallbackToPtr(handler), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlBegin", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
 	})
-
-	key := utils.RandString(10)
-
-	v._onLoadUrlBegin.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onLoadUrlBegin.Callbacks, key)
-	}
 }
 
 func (v *View) OnLoadUrlEnd(callback OnLoadUrlEndCallback) (stop func()) {
@@ -320,21 +411,79 @@ func (v *View) OnLoadUrlEnd(callback OnLoadUrlEndCallback) (stop func()) {
 			_url := PtrToString(url)
 			_job := WkeNetJob(job)
 			_buf := CopyBytes(buf, int(len))
-			for _, callback := range v._onLoadUrlEnd.Callbacks {
+			for _, callback := range v._onLoadUrlEnd.Callbacks() {
 				callback(_url, _job, _buf)
 			}
 			return 0
 		}
-		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlEnd", uintptr(v.Hwnd), CallbackToPtr(handler), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlEnd", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
 	})
 
-	key := utils.RandString(10)
+	return v._onLoadUrlEnd.Add(callback)
+}
 
-	v._onLoadUrlEnd.Callbacks[key] = callback
+// 请求加载完成时触发，length 为数据长度
+func (v *View) OnLoadUrlFinish(callback OnLoadUrlFinishCallback) (stop func()) {
 
-	return func() {
-		delete(v._onLoadUrlEnd.Callbacks, key)
-	}
+	v._onLoadUrlFinish.Register.Do(func() {
+		var handler = func(view, param, url, job, length uintptr) uintptr {
+			_url := PtrToString(url)
+			_job := WkeNetJob(job)
+			for _, callback := range v._onLoadUrlFinish.Callbacks() {
+				callback(_url, _job, int(int32(length)))
+			}
+			v._jobInfos.forget(_job) // 全部回调执行后清除，回调中仍可读取状态码等信息
+			return 0
+		}
+		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlFinish", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
+	})
+
+	return v._onLoadUrlFinish.Add(callback)
+}
+
+// 请求加载失败时触发
+func (v *View) OnLoadUrlFail(callback OnLoadUrlFailCallback) (stop func()) {
+
+	v._onLoadUrlFail.Register.Do(func() {
+		var handler = func(view, param, url, job uintptr) uintptr {
+			_url := PtrToString(url)
+			_job := WkeNetJob(job)
+			for _, callback := range v._onLoadUrlFail.Callbacks() {
+				callback(_url, _job)
+			}
+			v._jobInfos.forget(_job)
+			return 0
+		}
+		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlFail", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
+	})
+
+	return v._onLoadUrlFail.Add(callback)
+}
+
+// 请求发出前触发，可读取、修改请求头、url、请求体，或直接返回数据
+//
+// callback 返回 true 则中断、阻止后面的网络请求
+func (v *View) OnRequest(callback OnRequestCallback) (stop func()) {
+	return v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+		return callback(newRequest(v, job))
+	})
+}
+
+// 收到响应头时触发，可读取响应头、MIME 类型
+func (v *View) OnResponse(callback OnResponseCallback) (stop func()) {
+
+	v._onResponse.Register.Do(func() {
+		var handler = func(view, param, url, job uintptr) uintptr {
+			resp := newResponse(v, WkeNetJob(job))
+			for _, callback := range v._onResponse.Callbacks() {
+				callback(resp)
+			}
+			return 0
+		}
+		_, _, _ = v.mb.CallFunc("wkeOnLoadUrlHeadersReceived", uintptr(v.Hwnd), v.mb.NewCallback(handler), 0)
+	})
+
+	return v._onResponse.Add(callback)
 }
 
 func (v *View) OnDocumentReady(callback OnDocumentReadyCallback) (stop func()) {
@@ -342,22 +491,16 @@ func (v *View) OnDocumentReady(callback OnDocumentReadyCallback) (stop func()) {
 	v._onDocumentReady.Register.Do(func() {
 		var cb WkeDocumentReady2Callback = func(view WkeHandle, param uintptr, frame WkeWebFrameHandle) (voidRes uintptr) {
 
-			for _, callback := range v._onDocumentReady.Callbacks {
+			for _, callback := range v._onDocumentReady.Callbacks() {
 				callback(frame)
 			}
 
 			return 0
 		}
-		_, _, _ = v.mb.CallFunc("wkeOnDocumentReady2", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnDocumentReady2", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(10)
-
-	v._onDocumentReady.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onDocumentReady.Callbacks, key)
-	}
+	return v._onDocumentReady.Add(callback)
 }
 
 func (v *View) IsMainFrame(frameId WkeWebFrameHandle) bool {
@@ -413,7 +556,7 @@ func (v *View) RunJs(script string) JsValue {
 // 可指定 frame，会自动判断是否 document ready
 func (v *View) RunJsByFrame(frame WkeWebFrameHandle, script string) JsValue {
 
-	r1, _, _ := v.mb.CallFunc("wkeRunJsByFrame", uintptr(frame), StringToPtr(script), 0)
+	r1, _, _ := v.mb.CallFunc("wkeRunJsByFrame", uintptr(v.Hwnd), uintptr(frame), StringToPtr(script), BoolToPtr(false))
 
 	return JsValue(r1)
 }
@@ -428,40 +571,30 @@ func (v *View) OnDidCreateScriptContext(callback OnDidCreateScriptContextCallbac
 	v._onDidCreateScriptContext.Register.Do(func() {
 		var cb WkeDidCreateScriptContextCallback = func(view WkeHandle, param uintptr, frame WkeWebFrameHandle, context uintptr, exGroup, worldId int) (voidRes uintptr) {
 
-			for _, callback := range v._onDidCreateScriptContext.Callbacks {
+			for _, callback := range v._onDidCreateScriptContext.Callbacks() {
 				callback(frame, context, exGroup, worldId)
 			}
 			return 0
 		}
-		_, _, _ = v.mb.CallFunc("wkeOnDidCreateScriptContext", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnDidCreateScriptContext", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(8)
-	v._onDidCreateScriptContext.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onDidCreateScriptContext.Callbacks, key)
-	}
+	return v._onDidCreateScriptContext.Add(callback)
 }
 
 func (v *View) OnWillReleaseScriptContext(callback OnWillReleaseScriptContextCallback) (stop func()) {
 	v._onWillReleaseScriptContextCallback.Register.Do(func() {
 		var cb WkeWillReleaseScriptContextCallback = func(webView WkeHandle, param uintptr, frameId WkeWebFrameHandle, context uintptr, worldId int) (voidRes uintptr) {
-			for _, callback := range v._onWillReleaseScriptContextCallback.Callbacks {
+			for _, callback := range v._onWillReleaseScriptContextCallback.Callbacks() {
 				callback(frameId, context, worldId)
 			}
 			return 0
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnWillReleaseScriptContext", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnWillReleaseScriptContext", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(8)
-	v._onWillReleaseScriptContextCallback.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onWillReleaseScriptContextCallback.Callbacks, key)
-	}
+	return v._onWillReleaseScriptContextCallback.Add(callback)
 }
 
 func (v *View) watchScriptContextState() {
@@ -530,7 +663,7 @@ func (v *View) AddEventListener(selector, eventType string, callback func(), pre
 
 			key := selector + " " + eventType
 
-			callback, exist := view._onDomEvent.Callbacks[key]
+			callback, exist := view._onDomEvent.Get(key)
 			if !exist {
 				return
 			}
@@ -541,12 +674,12 @@ func (v *View) AddEventListener(selector, eventType string, callback func(), pre
 
 	key := selector + " " + eventType
 
-	v._onDomEvent.Callbacks[key] = callback // 增加 callback
+	v._onDomEvent.Set(key, callback) // 增加 callback
 
 	v.RunJs(script)
 
 	return func() {
-		delete(v._onDomEvent.Callbacks, key)
+		v._onDomEvent.Delete(key)
 	}
 }
 
@@ -554,7 +687,7 @@ func (v *View) RemoveEventListener(selector, eventType string) {
 
 	key := selector + " " + eventType
 
-	delete(v._onDomEvent.Callbacks, key)
+	v._onDomEvent.Delete(key)
 }
 
 func (v *View) bindDomEvents() {
@@ -609,22 +742,17 @@ func (v *View) OnConsole(callback OnConsoleCallback) (stop func()) {
 			sourceLine := int(_sourceLine)
 			stackTrace := v.mb.GetString(_stackTrace)
 
-			for _, callback := range v._onConsole.Callbacks {
+			for _, callback := range v._onConsole.Callbacks() {
 				callback(level, message, sourceName, sourceLine, stackTrace)
 			}
 
 			return 0
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnConsole", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnConsole", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(10)
-	v._onConsole.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onConsole.Callbacks, key)
-	}
+	return v._onConsole.Add(callback)
 }
 
 func (v *View) IsDocumentReady() bool {
@@ -632,30 +760,17 @@ func (v *View) IsDocumentReady() bool {
 	return p != 0
 }
 
-// 阻塞等待文档加载完成，仅限主frame
+// 阻塞等待文档加载完成，仅限主frame，超时返回 false。不能在 miniblink 线程中调用，详见 WaitFor
 func (v *View) WaitUntilDocumentReady(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	if v.IsDocumentReady() {
-		return true
-	}
-
-	rst := make(chan bool, 2)
-
-	select {
-	case <-time.After(timeout):
-		log.Error("等待文档加载超时")
-		rst <- false
-	default:
-		stop := func() {}
-		stop = v.OnDocumentReady(func(frame WkeWebFrameHandle) {
-			if v.IsMainFrame(frame) {
-				stop()
-				rst <- true
-			}
-		})
+	if err := v.WaitFor(ctx, WaitDocumentReady()); err != nil {
+		log.Error("等待文档加载失败：%v", err)
+		return false
 	}
 
-	return <-rst
+	return true
 }
 
 func (v *View) OnTitleChanged(callback OnTitleChangedCallback) (stop func()) {
@@ -664,22 +779,16 @@ func (v *View) OnTitleChanged(callback OnTitleChangedCallback) (stop func()) {
 		var cb WkeTitleChangedCallback = func(view WkeHandle, param uintptr, title WkeString) (voidRes uintptr) {
 			_title := v.mb.GetString(title)
 
-			for _, callback := range v._onTitleChanged.Callbacks {
+			for _, callback := range v._onTitleChanged.Callbacks() {
 				callback(_title)
 			}
 			return
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnTitleChanged", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnTitleChanged", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(10)
-
-	v._onTitleChanged.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onTitleChanged.Callbacks, key)
-	}
+	return v._onTitleChanged.Add(callback)
 }
 
 // 下载仅能使用一次，多次使用将覆盖前一个回调函数
@@ -688,22 +797,22 @@ func (v *View) OnDownload(callback OnDownloadCallback) (stop func()) {
 	v._onDownload.Register.Do(func() {
 		var cb WkeDownloadCallback = func(view WkeHandle, param uintptr, url uintptr) (voidRes uintptr) {
 			link := PtrToString(url)
-			for _, callback := range v._onDownload.Callbacks {
+			for _, callback := range v._onDownload.Callbacks() {
 				callback(link)
 			}
 			return
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnDownload", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnDownload", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
 	// key := utils.RandString(10)
 	key := "OnDownload" // 固定 KEY，仅支持一个回调函数
 
-	v._onDownload.Callbacks[key] = callback
+	v._onDownload.Set(key, callback)
 
 	return func() {
-		delete(v._onDownload.Callbacks, key)
+		v._onDownload.Delete(key)
 	}
 }
 
@@ -818,19 +927,14 @@ func (v *View) SetTransparent(transparent bool) {
 func (v *View) OnOtherLoad(callback OnOtherLoadCallback) (stop func()) {
 	v._onOtherLoad.Register.Do(func() {
 		var cb WkeOnOtherLoadCallback = func(webView WkeHandle, param uintptr, loadType WkeOtherLoadType, info *WkeTempCallbackInfo) (voidRes uintptr) {
-			for _, callback := range v._onOtherLoad.Callbacks {
+			for _, callback := range v._onOtherLoad.Callbacks() {
 				callback(loadType, info)
 			}
 			return
 		}
 
-		_, _, _ = v.mb.CallFunc("wkeOnOtherLoad", uintptr(v.Hwnd), CallbackToPtr(cb), 0)
+		_, _, _ = v.mb.CallFunc("wkeOnOtherLoad", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
 	})
 
-	key := utils.RandString(10)
-	v._onOtherLoad.Callbacks[key] = callback
-
-	return func() {
-		delete(v._onOtherLoad.Callbacks, key)
-	}
+	return v._onOtherLoad.Add(callback)
 }
@@ -26,5 +26,5 @@ func (v *View) ShowDevTools(devtoolsCallbacks ...func(devtools *View)) {
 
 		return 0
 	}
-	_, _, _ = v.mb.CallFunc("wkeShowDevtools", uintptr(v.Hwnd), StringToWCharPtr("http://__devtools__/inspector.html"), CallbackToPtr(callback), 0)
+	_, _, _ = v.mb.CallFunc("wkeShowDevtools", uintptr(v.Hwnd), StringToWCharPtr("http://__devtools__/inspector.html"), v.mb.NewCallback(callback), 0)
 }
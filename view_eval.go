@@ -0,0 +1,204 @@
+package blink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+// JS 端 Promise 完成时调用的原生函数：__mb_eval_settle(id, ok, value)
+const JS_EVAL_SETTLE = "__mb_eval_settle"
+
+// Eval 执行的脚本的 sourceURL，异常的位置、调用栈均相对于传入的 script，而不是外层的包装代码
+const JS_EVAL_SOURCE_URL = "mb-eval.js"
+
+// 等待 Promise 期间页面跳转、刷新或 view 销毁，Promise 不会再有结果
+var ErrScriptContextReleased = errors.New("script context released before the promise settled")
+
+type evalResult struct {
+	value interface{}
+	err   error
+}
+
+type evalCall struct {
+	view WkeHandle
+	ch   chan evalResult
+}
+
+// 等待 Promise 结果的 Eval 调用
+type evalPending struct {
+	calls sync.Map // id -> *evalCall
+}
+
+func (p *evalPending) add(id string, view WkeHandle) chan evalResult {
+	ch := make(chan evalResult, 1)
+	p.calls.Store(id, &evalCall{view: view, ch: ch})
+	return ch
+}
+
+func (p *evalPending) remove(id string) {
+	p.calls.Delete(id)
+}
+
+func (p *evalPending) settle(id string, result evalResult) {
+	call, ok := p.calls.LoadAndDelete(id)
+	if !ok {
+		return // 已超时或已结束
+	}
+	call.(*evalCall).ch <- result
+}
+
+// 结束 view 中所有等待 Promise 的调用
+func (p *evalPending) abort(view WkeHandle, err error) {
+	p.calls.Range(func(id, call interface{}) bool {
+		if call.(*evalCall).view == view {
+			p.settle(id.(string), evalResult{err: err})
+		}
+		return true
+	})
+}
+
+func (js *JS) registerEvalSettle() {
+	js.bindFunction(JS_EVAL_SETTLE, 3, func(es JsExecState) {
+		id := js.ToString(es, js.Arg(es, 0))
+
+		var result evalResult
+		if js.ToBoolean(es, js.Arg(es, 1)) {
+			result.value, result.err = js.ToGoValueE(es, js.Arg(es, 2))
+		} else {
+			result.err = js.rejection(es, js.Arg(es, 2))
+		}
+
+		js.evals.settle(id, result)
+	})
+}
+
+// 主 frame 的 script context 释放后，等待中的 Promise 不会再回调 JS_EVAL_SETTLE
+func (v *View) watchEvals() {
+	v.OnWillReleaseScriptContext(func(frame WkeWebFrameHandle, context uintptr, worldId int) {
+		if worldId != 0 || !v.IsMainFrame(frame) {
+			return
+		}
+		v.mb.js.evals.abort(v.Hwnd, ErrScriptContextReleased)
+	})
+	v.OnDestroy(func() {
+		v.mb.js.evals.abort(v.Hwnd, ErrScriptContextReleased)
+	})
+}
+
+// Promise 被 reject 的原因转为 *JsException，reason 通常为 Error 对象
+func (js *JS) rejection(es JsExecState, reason JsValue) *JsException {
+	exception := &JsException{Message: js.ToString(es, reason)}
+
+	if js.TypeOf(reason) == JsType_OBJECT {
+		if message := js.Get(es, reason, "message"); js.TypeOf(message) == JsType_STRING {
+			exception.Message = js.ToString(es, message)
+		}
+		if stack := js.Get(es, reason, "stack"); js.TypeOf(stack) == JsType_STRING {
+			exception.Stack = js.ToString(es, stack)
+		}
+	}
+
+	// Error 对象没有位置信息，取调用栈的第一帧
+	exception.ScriptResourceName, exception.LineNumber, exception.StartColumn = stackPosition(exception.Stack)
+
+	return exception
+}
+
+// 调用栈中的一帧： "    at fn (url:line:column)" 或 "    at url:line:column"
+var stackFrameRegexp = regexp.MustCompile(`^\s*at (?:.*\()?(.+):(\d+):(\d+)\)?$`)
+
+// 调用栈第一帧的位置，column 转为和 JsExceptionInfo 一致的从 0 开始
+func stackPosition(stack string) (url string, line, column int) {
+	for _, frame := range strings.Split(stack, "\n") {
+		m := stackFrameRegexp.FindStringSubmatch(frame)
+		if m == nil {
+			continue
+		}
+
+		line, _ = strconv.Atoi(m[2])
+		column, _ = strconv.Atoi(m[3])
+		if column > 0 {
+			column--
+		}
+		return m[1], line, column
+	}
+
+	return "", 0, 0
+}
+
+// 在主 frame 中执行 js 并返回结果，结果通过 JS.ToGoValueE 转换
+//
+// script 按 eval 执行，返回最后一个表达式的值，不需要 return；结果为 Promise 时等待其完成。
+// 抛出异常或 Promise 被 reject 时返回 *JsException，位置相对于 script，ScriptResourceName 为 JS_EVAL_SOURCE_URL。
+// ctx 结束时返回包含 ctx.Err() 的错误，等待 Promise 期间页面跳转、刷新时返回 ErrScriptContextReleased。
+// 不能在 miniblink 线程（如 view 的事件回调）中调用
+func (v *View) Eval(ctx context.Context, script string) (interface{}, error) {
+	if v.mb.threadID == currentThreadID() {
+		return nil, ErrWaitOnUIThread
+	}
+
+	id := utils.RandString(10)
+
+	// 单独 eval 传入的 script 并指定 sourceURL，异常的行号不受包装代码影响
+	source, _ := json.Marshal(script + "\n//# sourceURL=" + JS_EVAL_SOURCE_URL)
+
+	// jsEval 在原生调用中执行，返回后不会立即执行 microtask，已完成的 Promise 的 then 也要等到下一个任务才执行。
+	// setTimeout 投递一个空任务，保证没有其他页面事件时 then 也能尽快执行
+	wrapper := fmt.Sprintf(`var r = (0, eval)(%s);
+if (r && typeof r.then === "function") {
+	r.then(function (v) { window.%[2]s(%[3]q, true, v) }, function (e) { window.%[2]s(%[3]q, false, e) });
+	setTimeout(function () {}, 0);
+	return [true];
+}
+return [false, r];`, source, JS_EVAL_SETTLE, id)
+
+	js := v.mb.js
+	ch := js.evals.add(id, v.Hwnd)
+
+	<-v.mb.AddJob(func() {
+		es := js.GlobalExec(v.Hwnd)
+
+		result := js.Eval(es, wrapper)
+		if err := js.LastException(es); err != nil {
+			js.evals.settle(id, evalResult{err: err})
+			return
+		}
+
+		if js.ToBoolean(es, js.GetAt(es, result, 0)) {
+			return // 等待 Promise
+		}
+
+		value, err := js.ToGoValueE(es, js.GetAt(es, result, 1))
+		js.evals.settle(id, evalResult{value: value, err: err})
+	})
+
+	select {
+	case result := <-ch:
+		if errors.Is(result.err, ErrScriptContextReleased) {
+			return nil, fmt.Errorf("eval: %w", result.err)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		js.evals.remove(id)
+		return nil, fmt.Errorf("eval: %w", ctx.Err())
+	}
+}
+
+// 同 View.Eval，并将结果解码为 T 类型，规则同 InvokeAs
+func EvalAs[T any](ctx context.Context, v *View, script string) (result T, err error) {
+	res, err := v.Eval(ctx, script)
+	if err != nil {
+		return
+	}
+
+	err = decodeResult(res, &result)
+	return
+}
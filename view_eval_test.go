@@ -0,0 +1,43 @@
+package blink_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+)
+
+func TestEvalFailsOnScriptContextRelease(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Return("jsToBoolean", 1) // 结果为 Promise，等待 JS_EVAL_SETTLE
+	fake.Return("wkeIsMainFrame", 1)
+	view := app.CreateWebWindowPopup()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := view.Eval(context.Background(), "fetch('/slow')")
+		errCh <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !fake.Called("jsEvalW") {
+		if time.Now().After(deadline) {
+			t.Fatal("jsEvalW was not called")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 页面跳转，主 frame 的 script context 释放
+	fake.FireView("wkeOnWillReleaseScriptContext", testViewHandle, 1, 0, 0)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, blink.ErrScriptContextReleased) {
+			t.Fatalf("Eval() = %v, want ErrScriptContextReleased", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Eval() still waiting after the script context was released")
+	}
+}
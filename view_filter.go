@@ -0,0 +1,132 @@
+package blink
+
+import (
+	"sync"
+
+	"github.com/epkgs/blink/pkg/urlfilter"
+)
+
+// miniblink 资源类型对应的过滤规则资源类型
+var filterResourceTypes = map[WkeResourceType]urlfilter.ResourceType{
+	WKE_RESOURCE_TYPE_MAIN_FRAME:    urlfilter.Document,
+	WKE_RESOURCE_TYPE_SUB_FRAME:     urlfilter.Subdocument,
+	WKE_RESOURCE_TYPE_STYLESHEET:    urlfilter.Stylesheet,
+	WKE_RESOURCE_TYPE_SCRIPT:        urlfilter.Script,
+	WKE_RESOURCE_TYPE_IMAGE:         urlfilter.Image,
+	WKE_RESOURCE_TYPE_FAVICON:       urlfilter.Image,
+	WKE_RESOURCE_TYPE_FONT_RESOURCE: urlfilter.Font,
+	WKE_RESOURCE_TYPE_OBJECT:        urlfilter.Object,
+	WKE_RESOURCE_TYPE_MEDIA:         urlfilter.Media,
+	WKE_RESOURCE_TYPE_XHR:           urlfilter.XMLHttpRequest,
+	WKE_RESOURCE_TYPE_PING:          urlfilter.Ping,
+}
+
+// 请求过滤的统计
+type FilterStats struct {
+	Total   int            // 检查过的请求数
+	Blocked int            // 屏蔽的请求数
+	Rules   map[string]int // 各规则的命中次数
+}
+
+type filterStats struct {
+	FilterStats
+	locker sync.Mutex
+}
+
+// 资源类型，在 OnLoadUrlBegin 的回调之前从 wkeGetTempCallbackInfo 读取并按 job 记录，请求结束前均有效
+func (r *Request) ResourceType() (WkeResourceType, bool) {
+	info := r.View._jobInfos.get(r.Job)
+	return info.resourceType, info.hasResourceType
+}
+
+// 当前 OnLoadUrlBegin 回调中 job 的资源类型，来自 wkeGetTempCallbackInfo 的 WkeWillSendRequestInfo
+func (v *View) tempResourceType(job WkeNetJob) (WkeResourceType, bool) {
+	p, _, _ := v.mb.CallFunc("wkeGetTempCallbackInfo", uintptr(v.Hwnd))
+	if p == 0 {
+		return 0, false
+	}
+
+	info := AssertType[WkeTempCallbackInfo](p)
+	if info.Job != job || info.WillSendRequestInfo == nil {
+		return 0, false
+	}
+
+	return info.WillSendRequestInfo.ResourceType, true
+}
+
+// 在 NewView 中最先注册，过滤规则先于 Resource、OnRequestAsync 等其他回调执行
+func (v *View) registerFilter() {
+	v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+
+		req := newRequest(v, job)
+
+		filterReq := urlfilter.Request{URL: url, Type: urlfilter.Other}
+		if resourceType, ok := req.ResourceType(); ok {
+			if t, exist := filterResourceTypes[resourceType]; exist {
+				filterReq.Type = t
+			}
+		}
+
+		// 主 frame 的请求就是页面本身
+		if filterReq.Type == urlfilter.Document {
+			filterReq.DocumentURL = url
+		} else {
+			filterReq.DocumentURL = v.GetURL()
+		}
+
+		rule, blocked := v.mb.Filter.Match(filterReq)
+		if !blocked {
+			rule, blocked = v.Filter.Match(filterReq)
+		}
+
+		v._filterStats.record(rule, blocked)
+
+		if !blocked {
+			return false
+		}
+
+		req.Cancel()
+
+		for _, callback := range v._onBlocked.Callbacks() {
+			callback(req, rule)
+		}
+
+		return true
+	})
+}
+
+func (s *filterStats) record(rule string, blocked bool) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.Total++
+	if !blocked {
+		return
+	}
+
+	s.Blocked++
+	if s.Rules == nil {
+		s.Rules = make(map[string]int)
+	}
+	s.Rules[rule]++
+}
+
+// 当前 view 的请求过滤统计
+func (v *View) FilterStats() FilterStats {
+	v._filterStats.locker.Lock()
+	defer v._filterStats.locker.Unlock()
+
+	stats := v._filterStats.FilterStats
+	stats.Rules = make(map[string]int, len(v._filterStats.Rules))
+	for rule, count := range v._filterStats.Rules {
+		stats.Rules[rule] = count
+	}
+
+	return stats
+}
+
+// 请求被 Blink.Filter 或 View.Filter 屏蔽时触发，可用于记录日志
+func (v *View) OnBlocked(callback OnBlockedCallback) (stop func()) {
+
+	return v._onBlocked.Add(callback)
+}
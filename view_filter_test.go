@@ -0,0 +1,49 @@
+package blink_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/epkgs/blink"
+)
+
+func TestFilterRunsFirst(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	served := false
+	err := app.Resource.Handle("blocked.test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	view.OnLoadUrlBegin(func(url string, job blink.WkeNetJob) bool {
+		called = true
+		return false
+	})
+
+	if _, err := view.Filter.AddRule("||blocked.test^"); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocked []string
+	view.OnBlocked(func(req *blink.Request, rule string) {
+		blocked = append(blocked, rule)
+	})
+
+	if results := fireLoadUrlBegin(fake, "http://blocked.test/app.js", 1); results[0] != 1 {
+		t.Fatalf("results = %v", results)
+	}
+	if served || called {
+		t.Fatalf("blocked request reached later callbacks: served %v, called %v", served, called)
+	}
+	if len(blocked) != 1 || blocked[0] != "||blocked.test^" {
+		t.Fatalf("blocked = %v", blocked)
+	}
+	if stats := view.FilterStats(); stats.Total != 1 || stats.Blocked != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
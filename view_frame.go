@@ -0,0 +1,218 @@
+package blink
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+// 写入每个 frame 的 window 上，用于子 frame 查找父 frame
+const frameIdProp = "__mbFrameId"
+
+// 页面中的 frame，主 frame 或 iframe
+type Frame struct {
+	Handle WkeWebFrameHandle
+	View   *View
+}
+
+// miniblink 无法枚举 frame，由 script context 的创建、释放事件维护
+type frameTree struct {
+	locker  sync.RWMutex
+	parents map[WkeWebFrameHandle]WkeWebFrameHandle // frame -> 父 frame，主 frame 为 0
+	order   []WkeWebFrameHandle
+	tokens  map[string]WkeWebFrameHandle // 写入 window 的随机标识 -> frame，只认 GO 端生成的标识
+	tokenOf map[WkeWebFrameHandle]string
+}
+
+func (v *View) newFrame(handle WkeWebFrameHandle) *Frame {
+	return &Frame{Handle: handle, View: v}
+}
+
+func (v *View) watchFrames() {
+	v._frames.parents = make(map[WkeWebFrameHandle]WkeWebFrameHandle)
+	v._frames.tokens = make(map[string]WkeWebFrameHandle)
+	v._frames.tokenOf = make(map[WkeWebFrameHandle]string)
+
+	v.OnDidCreateScriptContext(func(frame WkeWebFrameHandle, context uintptr, exGroup, worldId int) {
+		if worldId != 0 {
+			return // 只处理主 world
+		}
+
+		f := v.newFrame(frame)
+		parent := f.findParent()
+
+		v._frames.locker.Lock()
+		_, exist := v._frames.parents[frame]
+		v._frames.parents[frame] = parent
+		if !exist {
+			v._frames.order = append(v._frames.order, frame)
+		}
+		v._frames.locker.Unlock()
+
+		for _, callback := range v._onFrameAttached.Callbacks() {
+			callback(f)
+		}
+	})
+
+	v.OnWillReleaseScriptContext(func(frame WkeWebFrameHandle, context uintptr, worldId int) {
+		if worldId != 0 {
+			return
+		}
+
+		v._frames.locker.Lock()
+		_, exist := v._frames.parents[frame]
+		delete(v._frames.parents, frame)
+		delete(v._frames.tokens, v._frames.tokenOf[frame])
+		delete(v._frames.tokenOf, frame)
+		for i, handle := range v._frames.order {
+			if handle == frame {
+				v._frames.order = append(v._frames.order[:i:i], v._frames.order[i+1:]...)
+				break
+			}
+		}
+		v._frames.locker.Unlock()
+
+		if !exist {
+			return
+		}
+
+		f := v.newFrame(frame)
+		for _, callback := range v._onFrameDetached.Callbacks() {
+			callback(f)
+		}
+	})
+}
+
+// 在 frame 的 window 上记录随机标识，并读取父 frame 的标识
+//
+// 在 script context 创建时、页面脚本执行前执行：标识为不可修改的属性，页面无法伪造 window.parent 或改写父 frame 的标识，
+// 读到的标识也必须是 GO 端为该 view 的 frame 生成的。跨域的父 frame 无法读取，按主 frame 处理
+func (f *Frame) findParent() WkeWebFrameHandle {
+	token := utils.RandString(16)
+
+	tree := &f.View._frames
+	tree.locker.Lock()
+	delete(tree.tokens, tree.tokenOf[f.Handle])
+	tree.tokens[token] = f.Handle
+	tree.tokenOf[f.Handle] = token
+	tree.locker.Unlock()
+
+	script := "Object.defineProperty(window," + strconv.Quote(frameIdProp) + ",{value:" + strconv.Quote(token) + "});"
+
+	if f.IsMain() {
+		f.View.RunJsByFrame(f.Handle, script)
+		return 0
+	}
+
+	script += "(function(){try{return String(window.parent." + frameIdProp + "||'')}catch(e){return ''}})()"
+	parentToken := f.View.mb.js.ToString(f.GlobalExec(), f.View.RunJsByFrame(f.Handle, script))
+
+	tree.locker.RLock()
+	parent, exist := tree.tokens[parentToken]
+	tree.locker.RUnlock()
+
+	if !exist || parent == f.Handle {
+		return f.View.GetMainWebFrame()
+	}
+
+	return parent
+}
+
+// 当前已创建 script context 的全部 frame，主 frame 始终在第一个
+func (v *View) Frames() []*Frame {
+	main := v.GetMainWebFrame()
+	frames := []*Frame{v.newFrame(main)}
+
+	v._frames.locker.RLock()
+	defer v._frames.locker.RUnlock()
+
+	for _, handle := range v._frames.order {
+		if handle != main {
+			frames = append(frames, v.newFrame(handle))
+		}
+	}
+
+	return frames
+}
+
+func (v *View) MainFrame() *Frame {
+	return v.newFrame(v.GetMainWebFrame())
+}
+
+// frame 创建 script context 时触发，frame 内导航也会触发，此前会先触发 OnFrameDetached
+func (v *View) OnFrameAttached(callback OnFrameCallback) (stop func()) {
+	return v._onFrameAttached.Add(callback)
+}
+
+// frame 释放 script context 时触发，如 iframe 被移除或导航
+func (v *View) OnFrameDetached(callback OnFrameCallback) (stop func()) {
+	return v._onFrameDetached.Add(callback)
+}
+
+func (f *Frame) IsMain() bool {
+	return f.View.IsMainFrame(f.Handle)
+}
+
+// 是否为跨进程的 frame
+func (f *Frame) IsRemote() bool {
+	r, _, _ := f.View.mb.CallFunc("wkeIsWebRemoteFrame", uintptr(f.View.Hwnd), uintptr(f.Handle))
+	return r != 0
+}
+
+func (f *Frame) URL() string {
+	r, _, _ := f.View.mb.CallFunc("wkeGetFrameUrl", uintptr(f.View.Hwnd), uintptr(f.Handle))
+	return PtrToString(r)
+}
+
+// frame 的 window.name
+func (f *Frame) Name() string {
+	return f.View.mb.js.ToString(f.GlobalExec(), f.RunJS("window.name"))
+}
+
+// 父 frame，主 frame 返回 nil
+func (f *Frame) Parent() *Frame {
+	if f.IsMain() {
+		return nil
+	}
+
+	f.View._frames.locker.RLock()
+	parent := f.View._frames.parents[f.Handle]
+	f.View._frames.locker.RUnlock()
+
+	if parent == 0 {
+		return f.View.MainFrame()
+	}
+	return f.View.newFrame(parent)
+}
+
+// 直接子 frame
+func (f *Frame) Children() []*Frame {
+	f.View._frames.locker.RLock()
+	defer f.View._frames.locker.RUnlock()
+
+	var children []*Frame
+	for _, handle := range f.View._frames.order {
+		if handle != f.Handle && f.View._frames.parents[handle] == f.Handle {
+			children = append(children, f.View.newFrame(handle))
+		}
+	}
+
+	return children
+}
+
+// 在 frame 中执行 js，返回最后一个表达式的值
+func (f *Frame) RunJS(script string) JsValue {
+	return f.View.RunJsByFrame(f.Handle, script)
+}
+
+// 在 frame 中插入样式
+func (f *Frame) InsertCSS(css string) {
+	_, _, _ = f.View.mb.CallFunc("wkeInsertCSSByFrame", uintptr(f.View.Hwnd), uintptr(f.Handle), StringToPtr(css))
+}
+
+// frame 的 jsExecState
+func (f *Frame) GlobalExec() JsExecState {
+	r, _, _ := f.View.mb.CallFunc("wkeGetGlobalExecByFrame", uintptr(f.View.Hwnd), uintptr(f.Handle))
+	return JsExecState(r)
+}
@@ -0,0 +1,72 @@
+package blink_test
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/epkgs/blink"
+)
+
+func TestFrameParentOnlyTrustsIssuedTokens(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	const main, child, nested, forged = 1, 2, 3, 4
+
+	tokenRegexp := regexp.MustCompile(`value:"(\w+)"`)
+
+	var mu sync.Mutex
+	tokens := map[uintptr]string{}
+	fake.Handle("wkeIsMainFrame", func(args ...uintptr) uintptr {
+		if args[1] == main {
+			return 1
+		}
+		return 0
+	})
+	fake.Return("wkeWebFrameGetMainFrame", main)
+	fake.Handle("wkeRunJsByFrame", func(args ...uintptr) uintptr {
+		script := blink.PtrToString(args[2])
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if m := tokenRegexp.FindStringSubmatch(script); m != nil {
+			tokens[args[1]] = m[1]
+		}
+		return args[1] // JsValue 为 frame，jsToString 时返回父 frame 的标识
+	})
+	fake.Handle("jsToString", func(args ...uintptr) uintptr {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var parentToken string
+		switch args[1] {
+		case nested:
+			parentToken = tokens[child]
+		case forged:
+			parentToken = "2" // 页面改写 window.parent.__mbFrameId 为其他 frame 的句柄
+		}
+
+		return fake.String(parentToken)
+	})
+
+	for _, frame := range []uintptr{main, child, nested, forged} {
+		fake.FireView("wkeOnDidCreateScriptContext", testViewHandle, frame, 0, 0, 0)
+	}
+
+	frames := map[blink.WkeWebFrameHandle]*blink.Frame{}
+	for _, f := range view.Frames() {
+		frames[f.Handle] = f
+	}
+
+	if p := frames[nested].Parent(); p == nil || p.Handle != child {
+		t.Fatalf("nested parent = %v, want %d", p, child)
+	}
+	if p := frames[forged].Parent(); p == nil || p.Handle != main {
+		t.Fatalf("forged parent = %v, want main frame", p)
+	}
+	if p := frames[main].Parent(); p != nil {
+		t.Fatalf("main parent = %v, want nil", p)
+	}
+}
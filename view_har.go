@@ -0,0 +1,493 @@
+package blink
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/epkgs/blink/pkg/har"
+)
+
+type HARConfig struct {
+	Bodies      bool // 是否记录响应数据，需调用 NetHookRequest 缓存网络数据，影响性能
+	MaxBodySize int  // 超过该大小的响应数据不记录，<= 0 表示不限制
+}
+
+// 记录响应数据，maxSize <= 0 表示不限制大小
+func WithHARBodies(maxSize int) func(*HARConfig) {
+	return func(conf *HARConfig) {
+		conf.Bodies = true
+		conf.MaxBodySize = maxSize
+	}
+}
+
+// 网络请求记录器，由 View.StartHARRecording 创建
+type HARRecorder struct {
+	view *View
+	conf HARConfig
+
+	locker  sync.Mutex
+	entries map[WkeNetJob]*harEntry
+	order   []*harEntry
+	pages   []*har.Page
+	stops   []func()
+	stopped bool
+}
+
+type harEntry struct {
+	entry har.Entry
+
+	begin    time.Time
+	headers  time.Time // 收到响应头的时间
+	served   bool      // 是否为直接返回的响应
+	finished bool
+}
+
+// 未完成请求的 Entry.Comment
+const harUnfinished = "unfinished"
+
+// 开始记录当前 view 的全部网络请求，调用 Stop 结束并得到 HAR 1.2 格式的记录
+//
+// 在 OnLoadUrlBegin 的回调之前记录，被资源绑定、请求过滤等拦截的请求同样会被记录。
+// 由 Resource、AsyncJob.Respond、ReplayHAR 直接返回的响应记录返回的状态码；
+// 网络响应的状态码来自 OnOtherLoad 的 WKE_DID_GET_RESPONSE_DETAILS，没有收到时为 0
+func (v *View) StartHARRecording(withConfig ...func(*HARConfig)) *HARRecorder {
+	conf := HARConfig{}
+	for _, fn := range withConfig {
+		fn(&conf)
+	}
+
+	rec := &HARRecorder{
+		view:    v,
+		conf:    conf,
+		entries: make(map[WkeNetJob]*harEntry),
+	}
+
+	rec.stops = append(rec.stops,
+		v.observeNetJobs(&netObserver{begin: rec.onBegin, served: rec.onServed, ended: rec.onEnded}),
+		v.OnResponse(rec.onResponse),
+		v.OnLoadUrlEnd(rec.onEnd),
+		v.OnLoadUrlFinish(rec.onFinish),
+		v.OnLoadUrlFail(rec.onFail),
+		v.OnDocumentReady(rec.onDocumentReady),
+		v.OnTitleChanged(rec.onTitleChanged),
+		v.OnOtherLoad(rec.onOtherLoad),
+	)
+
+	return rec
+}
+
+func (rec *HARRecorder) onBegin(url string, job WkeNetJob) {
+	req := newRequest(rec.view, job)
+	now := time.Now()
+
+	method := req.Method()
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	entry := &harEntry{
+		begin: now,
+		entry: har.Entry{
+			StartedDateTime: now,
+			Request: har.Request{
+				Method:      method,
+				URL:         url,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []har.Cookie{},
+				Headers:     har.Headers(req.Headers()),
+				QueryString: har.QueryString(url),
+				HeadersSize: -1,
+				BodySize:    0,
+			},
+			Response: har.Response{
+				Cookies:     []har.Cookie{},
+				Headers:     []har.NameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Timings: har.Timings{Blocked: -1, DNS: -1, Connect: -1, Send: 0, Wait: -1, Receive: -1, SSL: -1},
+		},
+	}
+
+	if method != http.MethodGet {
+		if body, err := req.Body(); err == nil && len(body) > 0 {
+			entry.entry.Request.BodySize = int64(len(body))
+			entry.entry.Request.PostData = &har.PostData{
+				MimeType: req.Header("Content-Type"),
+				Params:   []har.Param{},
+				Text:     string(body),
+			}
+		}
+	}
+
+	if resourceType, ok := req.ResourceType(); ok && resourceType == WKE_RESOURCE_TYPE_MAIN_FRAME {
+		rec.newPage(url, now)
+	}
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	if rec.stopped {
+		return
+	}
+
+	if len(rec.pages) > 0 {
+		entry.entry.Pageref = rec.pages[len(rec.pages)-1].ID
+	}
+
+	rec.entries[job] = entry
+	rec.order = append(rec.order, entry)
+
+	if rec.conf.Bodies {
+		rec.view.mb.NetHookRequest(job)
+	}
+}
+
+func (rec *HARRecorder) newPage(url string, now time.Time) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	if rec.stopped {
+		return
+	}
+
+	rec.pages = append(rec.pages, &har.Page{
+		StartedDateTime: now,
+		ID:              fmt.Sprintf("page_%d", len(rec.pages)+1),
+		Title:           url,
+		PageTimings:     har.PageTimings{OnContentLoad: -1, OnLoad: -1},
+	})
+}
+
+func (rec *HARRecorder) currentPage() *har.Page {
+	if len(rec.pages) == 0 {
+		return nil
+	}
+	return rec.pages[len(rec.pages)-1]
+}
+
+func (rec *HARRecorder) onResponse(resp *Response) {
+	header := resp.Headers()
+	mimeType := resp.MIMEType()
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[resp.Job]
+	if !ok || entry.served {
+		return
+	}
+
+	entry.headers = time.Now()
+
+	response := &entry.entry.Response
+	response.HTTPVersion = "HTTP/1.1"
+	response.Headers = har.Headers(header)
+	response.RedirectURL = header.Get("Location")
+	response.Content.MimeType = mimeType
+
+	entry.entry.Timings.Wait = milliseconds(entry.headers.Sub(entry.begin))
+}
+
+// 直接返回的响应，状态码、响应头、数据均已知
+func (rec *HARRecorder) onServed(job WkeNetJob, served *responseRecorder) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[job]
+	if !ok {
+		return
+	}
+
+	entry.headers = time.Now()
+	entry.served = true
+
+	body := served.body.Bytes()
+
+	response := &entry.entry.Response
+	response.Status = served.status
+	response.StatusText = http.StatusText(served.status)
+	response.HTTPVersion = "HTTP/1.1"
+	response.Headers = har.Headers(served.header)
+	response.RedirectURL = served.header.Get("Location")
+	response.BodySize = int64(len(body))
+	response.Content.MimeType = served.header.Get("Content-Type")
+	response.Content.Size = int64(len(body))
+	if rec.conf.Bodies && (rec.conf.MaxBodySize <= 0 || len(body) <= rec.conf.MaxBodySize) {
+		response.Content.SetBody(body)
+	}
+
+	entry.entry.Timings.Wait = milliseconds(entry.headers.Sub(entry.begin))
+}
+
+// 不交给网络层的请求结束，没有返回数据的为被取消
+func (rec *HARRecorder) onEnded(job WkeNetJob) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[job]
+	if !ok || entry.finished {
+		return
+	}
+
+	entry.finish(time.Now())
+	if !entry.served {
+		entry.entry.Response.Error = "net::ERR_ABORTED"
+	}
+}
+
+func (rec *HARRecorder) onEnd(url string, job WkeNetJob, buf []byte) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[job]
+	if !ok || !rec.conf.Bodies {
+		return
+	}
+
+	content := &entry.entry.Response.Content
+	if rec.conf.MaxBodySize > 0 && len(buf) > rec.conf.MaxBodySize {
+		content.Size = int64(len(buf))
+		content.Comment = "body exceeds MaxBodySize"
+		return
+	}
+
+	content.SetBody(buf)
+}
+
+func (rec *HARRecorder) onFinish(url string, job WkeNetJob, length int) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[job]
+	if !ok || entry.finished {
+		return
+	}
+
+	entry.finish(time.Now())
+	entry.entry.Response.BodySize = int64(length)
+	if entry.entry.Response.Content.Size == 0 {
+		entry.entry.Response.Content.Size = int64(length)
+	}
+}
+
+func (rec *HARRecorder) onFail(url string, job WkeNetJob) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[job]
+	if !ok || entry.finished {
+		return
+	}
+
+	entry.finish(time.Now())
+	entry.entry.Response.Error = "net::ERR_FAILED"
+}
+
+func (entry *harEntry) finish(now time.Time) {
+	entry.finished = true
+
+	if !entry.headers.IsZero() {
+		entry.entry.Timings.Receive = milliseconds(now.Sub(entry.headers))
+	} else {
+		entry.entry.Timings.Wait = milliseconds(now.Sub(entry.begin))
+		entry.entry.Timings.Receive = 0
+	}
+
+	entry.entry.Time = milliseconds(now.Sub(entry.begin))
+}
+
+func (rec *HARRecorder) onDocumentReady(frame WkeWebFrameHandle) {
+	if !rec.view.IsMainFrame(frame) {
+		return
+	}
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	if page := rec.currentPage(); page != nil {
+		page.PageTimings.OnContentLoad = milliseconds(time.Since(page.StartedDateTime))
+	}
+}
+
+func (rec *HARRecorder) onTitleChanged(title string) {
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	if page := rec.currentPage(); page != nil {
+		page.Title = title
+	}
+}
+
+func (rec *HARRecorder) onOtherLoad(loadType WkeOtherLoadType, info *WkeTempCallbackInfo) {
+	if loadType == WKE_DID_GET_RESPONSE_DETAILS || loadType == WKE_DID_GET_REDIRECT_REQUEST {
+		rec.onStatus(info)
+		return
+	}
+	if loadType != WKE_DID_STOP_LOADING {
+		return
+	}
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	if page := rec.currentPage(); page != nil && page.PageTimings.OnLoad < 0 {
+		page.PageTimings.OnLoad = milliseconds(time.Since(page.StartedDateTime))
+	}
+}
+
+// 网络响应的状态码，跳转时先收到 3xx，跳转后的响应再覆盖
+func (rec *HARRecorder) onStatus(info *WkeTempCallbackInfo) {
+	if info == nil || info.WillSendRequestInfo == nil {
+		return
+	}
+
+	code := int(info.WillSendRequestInfo.HTTPResponseCode)
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	entry, ok := rec.entries[info.Job]
+	if !ok || entry.served {
+		return
+	}
+
+	entry.entry.Response.Status = code
+	entry.entry.Response.StatusText = http.StatusText(code)
+}
+
+// 结束记录，返回记录的结果，未完成的请求也会包含在内。可多次调用，之后的调用返回同样的记录
+func (rec *HARRecorder) Stop() *har.HAR {
+	rec.locker.Lock()
+	stops := rec.stops
+	rec.stops = nil
+	rec.stopped = true
+	rec.locker.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+
+	rec.locker.Lock()
+	defer rec.locker.Unlock()
+
+	result := har.New(har.Creator{Name: "github.com/epkgs/blink", Version: rec.view.mb.VersionString()})
+
+	for _, page := range rec.pages {
+		result.Log.Pages = append(result.Log.Pages, *page)
+	}
+
+	for _, entry := range rec.order {
+		e := entry.entry
+		if !entry.finished {
+			e.Time = milliseconds(time.Since(entry.begin))
+			e.Comment = harUnfinished
+		}
+		result.Log.Entries = append(result.Log.Entries, e)
+	}
+
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+type HARReplayConfig struct {
+	Strict bool // 为 true 时取消记录中没有或没有记录数据的请求，否则正常发出网络请求
+}
+
+// 记录中没有或没有记录数据的请求直接取消
+func WithHARStrict() func(*HARReplayConfig) {
+	return func(conf *HARReplayConfig) {
+		conf.Strict = true
+	}
+}
+
+// 使用 HAR 记录回放网络请求，按请求方法和 url 匹配，同一 url 的多次请求按记录顺序依次返回，记录用完后重复最后一条
+//
+// 带 Location 的 3xx 记录（或状态码未知的记录）会改为请求跳转后的 url；其他记录按记录的状态码、响应头、数据返回，
+// 录制回放过程的 HAR 中状态码与原记录一致，但 miniblink 无法设置状态码，页面得到的状态码均为 200。
+// 未完成或没有记录数据（记录时未开启 WithHARBodies）的请求正常发出网络请求
+func (v *View) ReplayHAR(h *har.HAR, withConfig ...func(*HARReplayConfig)) (stop func()) {
+	conf := HARReplayConfig{}
+	for _, fn := range withConfig {
+		fn(&conf)
+	}
+
+	var locker sync.Mutex
+	served := make(map[string]int)
+
+	find := func(method, url string) *har.Entry {
+		locker.Lock()
+		defer locker.Unlock()
+
+		key := method + " " + url
+		entry := h.Find(method, url, served[key])
+		if entry == nil && served[key] > 0 {
+			return h.Find(method, url, served[key]-1)
+		}
+		if entry != nil {
+			served[key]++
+		}
+		return entry
+	}
+
+	return v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+		req := newRequest(v, job)
+
+		method := req.Method()
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		entry := find(method, url)
+		if entry != nil && entry.Response.Error != "" {
+			req.Cancel()
+			return true
+		}
+
+		if entry != nil && entry.Response.RedirectURL != "" && (entry.Response.Status == 0 || entry.Response.Status >= 300 && entry.Response.Status < 400) {
+			if location, err := resolveLocation(url, entry.Response.RedirectURL); err == nil {
+				req.ChangeURL(location)
+			}
+			return false
+		}
+
+		if entry == nil || entry.Comment == harUnfinished || !entry.Response.Content.HasBody() {
+			if conf.Strict {
+				req.Cancel()
+				return true
+			}
+			return false
+		}
+
+		body, err := entry.Response.Content.Body()
+		if err != nil {
+			req.Cancel()
+			return true
+		}
+
+		rec := newResponseRecorder()
+		for key, values := range har.ToHeader(entry.Response.Headers) {
+			switch key {
+			case "Content-Encoding", "Transfer-Encoding":
+				continue // 记录的是解码后的数据
+			}
+			rec.header[key] = values
+		}
+		if entry.Response.Content.MimeType != "" {
+			rec.header.Set("Content-Type", entry.Response.Content.MimeType)
+		}
+		if entry.Response.Status != 0 {
+			rec.WriteHeader(entry.Response.Status)
+		}
+		_, _ = rec.Write(body)
+
+		writeRecorder(req.Response(), url, rec)
+
+		return true
+	})
+}
@@ -0,0 +1,100 @@
+package blink_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/har"
+)
+
+func TestHARRecordsServedAndBlockedRequests(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	err := app.Resource.Handle("local.test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("missing"))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	view.Filter.AddHosts("ads.test")
+
+	rec := view.StartHARRecording(blink.WithHARBodies(0))
+
+	fireLoadUrlBegin(fake, "http://local.test/a.txt", 1)
+	fireLoadUrlBegin(fake, "http://ads.test/banner.js", 2)
+	fireLoadUrlBegin(fake, "http://network.test/", 3)
+	fireResponseDetails(fake, 3, http.StatusNotFound)
+	fake.FireView("wkeOnLoadUrlFinish", testViewHandle, 0, 3, 10)
+
+	result := rec.Stop()
+	if len(result.Log.Entries) != 3 {
+		t.Fatalf("entries = %+v", result.Log.Entries)
+	}
+
+	served := result.Log.Entries[0].Response
+	if served.Status != http.StatusNotFound || served.Content.Text != "missing" {
+		t.Fatalf("served response = %+v", served)
+	}
+
+	if blocked := result.Log.Entries[1]; blocked.Response.Error == "" || blocked.Comment != "" {
+		t.Fatalf("blocked entry = %+v", blocked)
+	}
+
+	network := result.Log.Entries[2].Response
+	if network.Status != http.StatusNotFound || network.StatusText != "Not Found" || network.BodySize != 10 {
+		t.Fatalf("network response = %+v", network)
+	}
+}
+
+func TestReplayHARFallsBackWithoutBody(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	h := har.New(har.Creator{Name: "test"})
+	h.Log.Entries = []har.Entry{
+		{Request: har.Request{Method: "GET", URL: "http://replay.test/body"}, Response: har.Response{Status: 200, Content: har.Content{Size: 2, Text: "ok"}}},
+		{Request: har.Request{Method: "GET", URL: "http://replay.test/nobody"}, Response: har.Response{Status: 200, Content: har.Content{Size: 100}}},
+	}
+	view.ReplayHAR(h)
+
+	if results := fireLoadUrlBegin(fake, "http://replay.test/body", 1); results[0] != 1 {
+		t.Fatal("recorded body was not served")
+	}
+	if results := fireLoadUrlBegin(fake, "http://replay.test/nobody", 2); results[0] != 0 {
+		t.Fatal("entry without body did not fall back to the network")
+	}
+	if fake.Called("wkeNetCancelRequest") {
+		t.Fatal("request was cancelled")
+	}
+}
+
+func TestReplayHARKeepsStatus(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	h := har.New(har.Creator{Name: "test"})
+	h.Log.Entries = []har.Entry{
+		{Request: har.Request{Method: "GET", URL: "http://replay.test/ok"}, Response: har.Response{Status: 200, Content: har.Content{Size: 2, Text: "ok"}}},
+		{Request: har.Request{Method: "GET", URL: "http://replay.test/missing"}, Response: har.Response{Status: 404, Content: har.Content{Size: 7, Text: "missing"}}},
+	}
+	view.ReplayHAR(h)
+
+	rec := view.StartHARRecording()
+	fireLoadUrlBegin(fake, "http://replay.test/ok", 1)
+	fireLoadUrlBegin(fake, "http://replay.test/missing", 2)
+	result := rec.Stop()
+
+	if len(result.Log.Entries) != 2 {
+		t.Fatalf("entries = %+v", result.Log.Entries)
+	}
+	if status := result.Log.Entries[0].Response.Status; status != http.StatusOK {
+		t.Fatalf("ok status = %d", status)
+	}
+	if status := result.Log.Entries[1].Response.Status; status != http.StatusNotFound {
+		t.Fatalf("missing status = %d", status)
+	}
+}
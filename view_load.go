@@ -0,0 +1,179 @@
+package blink
+
+import (
+	"context"
+	"fmt"
+	netUrl "net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// 页面加载失败或被取消
+type LoadError struct {
+	URL    string
+	Result WkeLoadingResult // WKE_LOADING_FAILED 或 WKE_LOADING_CANCELED
+	Reason string
+}
+
+func (e *LoadError) Error() string {
+	if e.Result == WKE_LOADING_CANCELED {
+		return fmt.Sprintf("load %s canceled", e.URL)
+	}
+	return fmt.Sprintf("load %s failed: %s", e.URL, e.Reason)
+}
+
+// 页面加载的结果，主 frame 加载完成或失败时完成
+//
+// 不要在 miniblink 线程（如 view 的事件回调）中等待，否则会死锁
+type LoadFuture struct {
+	done chan struct{}
+	once sync.Once
+	url  string
+	err  error
+}
+
+func newLoadFuture() *LoadFuture {
+	return &LoadFuture{done: make(chan struct{})}
+}
+
+func (f *LoadFuture) resolve(url string, err error) {
+	f.once.Do(func() {
+		f.url, f.err = url, err
+		close(f.done)
+	})
+}
+
+// 加载结束时关闭
+func (f *LoadFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// 等待加载结束，加载失败时返回 *LoadError
+func (f *LoadFuture) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 加载结束的 url，未结束时返回空字符串
+func (f *LoadFuture) URL() string {
+	select {
+	case <-f.done:
+		return f.url
+	default:
+		return ""
+	}
+}
+
+// 在 miniblink 线程中注册加载结束的回调后再开始加载，避免错过事件
+//
+// 开始加载会取消之前未完成的加载，之前加载的 WKE_LOADING_CANCELED 可能在之后才触发，
+// 因此只接受 url 与本次加载相同的取消，成功或失败则不论 url（重定向后 url 会变化）
+func (v *View) load(url string, start func()) *LoadFuture {
+	future := newLoadFuture()
+
+	run := func() {
+		var stop func()
+		stop = v.OnLoadingFinish(func(finishedURL string, result WkeLoadingResult, failedReason string) {
+			if result == WKE_LOADING_CANCELED && !sameURL(finishedURL, url) {
+				return
+			}
+
+			stop()
+
+			if result == WKE_LOADING_SUCCEEDED {
+				future.resolve(finishedURL, nil)
+				return
+			}
+			future.resolve(finishedURL, &LoadError{URL: finishedURL, Result: result, Reason: failedReason})
+		})
+
+		v._loads.start()
+		start()
+	}
+
+	if v.mb.threadID == currentThreadID() {
+		run()
+	} else {
+		<-v.mb.AddJob(run)
+	}
+
+	return future
+}
+
+// 比较两个 url 是否指向同一页面，忽略 scheme、host 的大小写、空路径与 "/" 的区别及 fragment
+func sameURL(a, b string) bool {
+	ua, errA := netUrl.Parse(a)
+	ub, errB := netUrl.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	normalize := func(u *netUrl.URL) string {
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		if strings.EqualFold(u.Scheme, "file") {
+			path = strings.ToLower(strings.TrimPrefix(path, "/"))
+		}
+		return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path + "?" + u.RawQuery
+	}
+
+	return normalize(ua) == normalize(ub)
+}
+
+// 本地文件路径对应的 file:// url
+func fileURL(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // windows 的盘符路径
+	}
+	return (&netUrl.URL{Scheme: "file", Path: path}).String()
+}
+
+// 加载 html 字符串，页面的 url 为 about:blank，相对路径的资源无法加载，需要时使用 LoadHTMLWithBaseURL
+//
+//	_ = view.LoadHTML(html).Wait(ctx)
+//	_ = view.SaveToPDF(file)
+func (v *View) LoadHTML(html string) *LoadFuture {
+	return v.load("about:blank", func() {
+		_, _, _ = v.mb.CallFunc("wkeLoadHTML", uintptr(v.Hwnd), StringToPtr(html))
+	})
+}
+
+// 加载 html 字符串，相对路径的资源按 baseURL 解析，baseURL 可以是 Resource 绑定的域名
+func (v *View) LoadHTMLWithBaseURL(html, baseURL string) *LoadFuture {
+	return v.load(baseURL, func() {
+		_, _, _ = v.mb.CallFunc("wkeLoadHtmlWithBaseUrl", uintptr(v.Hwnd), StringToPtr(html), StringToPtr(baseURL))
+	})
+}
+
+// 加载本地文件，相对路径按当前工作目录解析
+func (v *View) LoadFile(path string) *LoadFuture {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	return v.load(fileURL(path), func() {
+		_, _, _ = v.mb.CallFunc("wkeLoadFileW", uintptr(v.Hwnd), StringToWCharPtr(path))
+	})
+}
+
+// 以 POST 方式导航到 url，Content-Type 为 application/x-www-form-urlencoded
+func (v *View) PostURL(url string, body []byte) *LoadFuture {
+	return v.load(url, func() {
+		var data uintptr
+		if len(body) > 0 {
+			data = uintptr(unsafe.Pointer(&body[0]))
+		}
+		_, _, _ = v.mb.CallFunc("wkePostURL", uintptr(v.Hwnd), StringToPtr(url), data, uintptr(len(body)))
+		runtime.KeepAlive(body)
+	})
+}
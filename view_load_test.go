@@ -0,0 +1,66 @@
+package blink_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/fakebackend"
+)
+
+// 触发 wkeOnLoadingFinish，测试中 wkeGetString 直接返回传入的指针
+func fireLoadingFinish(fake *fakebackend.Backend, url string, result blink.WkeLoadingResult) {
+	fake.FireView("wkeOnLoadingFinish", testViewHandle, fake.String(url), uintptr(result), 0)
+}
+
+func TestLoadFutureIgnoresStaleCancel(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Handle("wkeGetString", func(args ...uintptr) uintptr { return args[0] })
+	view := app.CreateWebWindowPopup()
+
+	future := view.PostURL("http://next.test/form", []byte("a=1"))
+
+	// 被新加载打断的上一个页面
+	fireLoadingFinish(fake, "http://previous.test/", blink.WKE_LOADING_CANCELED)
+	select {
+	case <-future.Done():
+		t.Fatal("future resolved by the previous load's cancel")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	fireLoadingFinish(fake, "http://next.test/form", blink.WKE_LOADING_CANCELED)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var loadErr *blink.LoadError
+	if err := future.Wait(ctx); !errors.As(err, &loadErr) || loadErr.Result != blink.WKE_LOADING_CANCELED {
+		t.Fatalf("Wait() = %v, want canceled LoadError", err)
+	}
+	if future.URL() != "http://next.test/form" {
+		t.Fatalf("URL() = %q", future.URL())
+	}
+}
+
+func TestLoadFutureSucceeded(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Handle("wkeGetString", func(args ...uintptr) uintptr { return args[0] })
+	view := app.CreateWebWindowPopup()
+
+	future := view.LoadHTML("<p>hi</p>")
+	if !fake.Called("wkeLoadHTML") {
+		t.Fatal("wkeLoadHTML was not called")
+	}
+
+	// 成功不论 url，如重定向后的页面
+	fireLoadingFinish(fake, "http://redirected.test/", blink.WKE_LOADING_SUCCEEDED)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := future.Wait(ctx); err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package blink
+
+import (
+	netUrl "net/url"
+	"strings"
+)
+
+// 后退，无法后退时返回 false
+func (v *View) GoBack() bool {
+	v._loads.start()
+	r, _, _ := v.mb.CallFunc("wkeGoBack", uintptr(v.Hwnd))
+	if r == 0 {
+		v._loads.abort()
+	}
+	return r != 0
+}
+
+// 前进，无法前进时返回 false
+func (v *View) GoForward() bool {
+	v._loads.start()
+	r, _, _ := v.mb.CallFunc("wkeGoForward", uintptr(v.Hwnd))
+	if r == 0 {
+		v._loads.abort()
+	}
+	return r != 0
+}
+
+func (v *View) CanGoBack() bool {
+	r, _, _ := v.mb.CallFunc("wkeCanGoBack", uintptr(v.Hwnd))
+	return r != 0
+}
+
+func (v *View) CanGoForward() bool {
+	r, _, _ := v.mb.CallFunc("wkeCanGoForward", uintptr(v.Hwnd))
+	return r != 0
+}
+
+// 按偏移跳转历史记录，-1 为后退，1 为前进
+func (v *View) GoToOffset(offset int) {
+	_, _, _ = v.mb.CallFunc("wkeGoToOffset", uintptr(v.Hwnd), uintptr(offset))
+}
+
+// 跳转到指定的历史记录
+func (v *View) GoToIndex(index int) {
+	_, _, _ = v.mb.CallFunc("wkeGoToIndex", uintptr(v.Hwnd), uintptr(index))
+}
+
+func (v *View) StopLoading() {
+	_, _, _ = v.mb.CallFunc("wkeStopLoading", uintptr(v.Hwnd))
+}
+
+func (v *View) IsLoading() bool {
+	r, _, _ := v.mb.CallFunc("wkeIsLoading", uintptr(v.Hwnd))
+	return r != 0
+}
+
+func (v *View) IsLoadingSucceeded() bool {
+	r, _, _ := v.mb.CallFunc("wkeIsLoadingSucceeded", uintptr(v.Hwnd))
+	return r != 0
+}
+
+func (v *View) IsLoadingFailed() bool {
+	r, _, _ := v.mb.CallFunc("wkeIsLoadingFailed", uintptr(v.Hwnd))
+	return r != 0
+}
+
+func (v *View) IsLoadingCompleted() bool {
+	r, _, _ := v.mb.CallFunc("wkeIsLoadingCompleted", uintptr(v.Hwnd))
+	return r != 0
+}
+
+// 导航开始前触发，可以添加多个 callback，任一 callback 返回 false 则取消导航
+func (v *View) OnNavigation(callback OnNavigationCallback) (stop func()) {
+
+	v._onNavigation.Register.Do(func() {
+		var cb = func(view, param uintptr, navigationType WkeNavigationType, url WkeString) (boolRes uintptr) {
+			_url := v.mb.GetString(url)
+
+			for _, callback := range v._onNavigation.Callbacks() {
+				if !callback(navigationType, _url) {
+					return BoolToPtr(false)
+				}
+			}
+			return BoolToPtr(true)
+		}
+
+		_, _, _ = v.mb.CallFunc("wkeOnNavigation", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
+	})
+
+	return v._onNavigation.Add(callback)
+}
+
+// url 改变时触发，包括 iframe 的 url 以及 pushState、hash 的变化
+func (v *View) OnURLChanged(callback OnURLChangedCallback) (stop func()) {
+
+	v._onURLChanged.Register.Do(func() {
+		var cb = func(view, param uintptr, frame WkeWebFrameHandle, url WkeString) (voidRes uintptr) {
+			_url := v.mb.GetString(url)
+
+			for _, callback := range v._onURLChanged.Callbacks() {
+				callback(frame, _url)
+			}
+			return
+		}
+
+		_, _, _ = v.mb.CallFunc("wkeOnURLChanged2", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
+	})
+
+	return v._onURLChanged.Add(callback)
+}
+
+// 页面加载结束时触发，result 为 WKE_LOADING_FAILED 时 failedReason 为失败原因
+func (v *View) OnLoadingFinish(callback OnLoadingFinishCallback) (stop func()) {
+
+	v._onLoadingFinish.Register.Do(func() {
+		var cb = func(view, param uintptr, url WkeString, result WkeLoadingResult, failedReason WkeString) (voidRes uintptr) {
+			_url := v.mb.GetString(url)
+			_reason := ""
+			if failedReason != 0 {
+				_reason = v.mb.GetString(failedReason)
+			}
+
+			for _, callback := range v._onLoadingFinish.Callbacks() {
+				callback(_url, result, _reason)
+			}
+			return
+		}
+
+		_, _, _ = v.mb.CallFunc("wkeOnLoadingFinish", uintptr(v.Hwnd), v.mb.NewCallback(cb), 0)
+	})
+
+	return v._onLoadingFinish.Add(callback)
+}
+
+// 限制只能导航到指定域名及其子域名，其他导航会被取消，about:blank 始终允许
+//
+//	stop := view.RestrictNavigation("example.com", "example.org")
+func (v *View) RestrictNavigation(domains ...string) (stop func()) {
+	allowed := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		allowed = append(allowed, strings.ToLower(strings.TrimPrefix(domain, ".")))
+	}
+
+	return v.OnNavigation(func(navigationType WkeNavigationType, url string) bool {
+		if url == "about:blank" {
+			return true
+		}
+
+		u, err := netUrl.Parse(url)
+		if err != nil {
+			return false
+		}
+
+		host := strings.ToLower(u.Hostname())
+		for _, domain := range allowed {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+		return false
+	})
+}
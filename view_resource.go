@@ -0,0 +1,207 @@
+package blink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	netUrl "net/url"
+	"path"
+	"strings"
+
+	"github.com/epkgs/blink/internal/log"
+	"github.com/epkgs/blink/pkg/resource"
+)
+
+// 内部重定向的最大次数
+const maxResourceRedirects = 10
+
+func (v *View) registerFileSystem() {
+	v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+
+		handler := v.mb.Resource.GetHandler(url)
+
+		// 不是 Resource 绑定的域名
+		if handler == nil {
+			return false
+		}
+
+		return v.serveHTTP(newRequest(v, job), url, handler)
+	})
+}
+
+// 通过 http.Handler 处理请求，并将状态码、响应头、MIME、数据写回 job
+//
+// miniblink 无法修改已拦截请求的状态码：
+//   - 3xx 且带 Location 时，目标仍由 Resource 处理则在内部跟随，否则修改请求 url 交由网络层加载
+//   - 206、304 无法表达，请求中去掉 Range 和条件请求头，handler 总是返回完整内容，响应带 `Accept-Ranges: none`
+//   - NetSetData 的数据不会被解压，请求中去掉 Accept-Encoding，handler 需返回未压缩的内容
+//   - 其它状态码照常返回响应头和数据，页面中看到的状态码为 200
+func (v *View) serveHTTP(req *Request, url string, handler http.Handler) bool {
+
+	body, err := req.Body()
+	if err != nil {
+		log.Error("read post body of %s: %s", url, err.Error())
+	}
+
+	method := req.Method()
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	header := req.Headers()
+	if rangeHeader := header.Get("Range"); rangeHeader != "" {
+		log.Debug("range %q of %s is not supported, serving the full content", rangeHeader, url)
+	}
+	for _, key := range []string{"Accept-Encoding", "Range", "If-Range", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+		header.Del(key)
+	}
+	if header.Get("Referer") == "" {
+		if referrer := req.Referrer(); referrer != "" {
+			header.Set("Referer", referrer)
+		}
+	}
+
+	for i := 0; i <= maxResourceRedirects; i++ {
+		rec := serveRecorder(v, handler, method, url, header, body)
+
+		location := rec.header.Get("Location")
+		if rec.status < 300 || rec.status >= 400 || location == "" {
+			writeRecorder(req.Response(), url, rec)
+			return true
+		}
+
+		target, err := resolveLocation(url, location)
+		if err != nil {
+			log.Error("invalid redirect location %q of %s: %s", location, url, err.Error())
+			writeRecorder(req.Response(), url, rec)
+			return true
+		}
+
+		// 303 及 301/302 的 POST 按浏览器的行为改为 GET
+		if rec.status == http.StatusSeeOther || ((rec.status == http.StatusMovedPermanently || rec.status == http.StatusFound) && method == http.MethodPost) {
+			method, body = http.MethodGet, nil
+		}
+
+		url = target
+		if handler = v.mb.Resource.GetHandler(url); handler == nil {
+			// 外部地址，交由网络层处理
+			req.ChangeURL(url)
+			return false
+		}
+	}
+
+	log.Error("too many redirects: %s", url)
+	req.Cancel()
+	return true
+}
+
+func resolveLocation(base, location string) (string, error) {
+	baseURL, err := netUrl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	locURL, err := netUrl.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(locURL).String(), nil
+}
+
+func serveRecorder(v *View, handler http.Handler, method, url string, header http.Header, body []byte) (rec *responseRecorder) {
+	rec = newResponseRecorder()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("serve %s %s: %v", method, url, r)
+			rec = newResponseRecorder()
+			rec.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprintf(rec, "%v", r)
+		}
+	}()
+
+	httpReq, err := http.NewRequestWithContext(v.mb.Ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		rec.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(rec, err.Error())
+		return rec
+	}
+	httpReq.Header = header.Clone()
+	httpReq.RequestURI = httpReq.URL.RequestURI()
+
+	handler.ServeHTTP(rec, httpReq)
+
+	return rec
+}
+
+func writeRecorder(resp *Response, url string, rec *responseRecorder) {
+	data := rec.body.Bytes()
+
+	contentType := rec.header.Get("Content-Type")
+	if contentType == "" {
+		contentType = mimeTypeOf(url, data)
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		resp.SetMIMEType(mediaType)
+	}
+
+	for key, values := range rec.header {
+		switch key {
+		case "Content-Length":
+			continue // 由 SetData 决定
+		case "Accept-Ranges":
+			continue // 直接返回的数据总是完整内容，无法返回 206
+		}
+		resp.SetHeader(key, strings.Join(values, ", "))
+	}
+	resp.SetHeader("Accept-Ranges", "none")
+
+	resp.SetData(data)
+
+	resp.View.serveNetJob(resp.Job, rec)
+}
+
+// 按扩展名获取 MIME，获取不到时按内容嗅探
+func mimeTypeOf(url string, data []byte) string {
+	if u, err := netUrl.Parse(url); err == nil {
+		if mimeType := resource.TypeByExtension(path.Ext(u.Path)); mimeType != "" {
+			return mimeType
+		}
+	}
+
+	if len(data) == 0 {
+		return ""
+	}
+
+	return http.DetectContentType(data)
+}
+
+// 记录 http.Handler 的响应
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = statusCode
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
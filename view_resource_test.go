@@ -0,0 +1,43 @@
+package blink_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/epkgs/blink"
+	"github.com/epkgs/blink/pkg/resource"
+)
+
+func TestResourceResponsesRefuseRanges(t *testing.T) {
+	app, fake := newTestApp(t)
+	app.CreateWebWindowPopup()
+
+	var mu sync.Mutex
+	headers := http.Header{}
+	fake.Handle("wkeNetSetHTTPHeaderField", func(args ...uintptr) uintptr {
+		mu.Lock()
+		defer mu.Unlock()
+
+		headers.Add(blink.PtrWCharToString(args[1]), blink.PtrWCharToString(args[2]))
+		return 0
+	})
+
+	files := fstest.MapFS{"video.mp4": {Data: []byte("0123456789")}}
+	if err := app.Resource.Handle("local.test", resource.FileServer(http.FS(files))); err != nil {
+		t.Fatal(err)
+	}
+
+	if results := fireLoadUrlBegin(fake, "http://local.test/video.mp4", 1); results[0] != 1 {
+		t.Fatal("resource request was not served")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// http.ServeContent 返回的 bytes 被替换，页面不会再发出分段请求
+	if got := headers.Values("Accept-Ranges"); len(got) != 1 || got[0] != "none" {
+		t.Fatalf("Accept-Ranges = %v, want [none]", got)
+	}
+}
@@ -0,0 +1,136 @@
+package blink
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/epkgs/blink/internal/log"
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+type RewriteResponseFunc func(resp *Response, body []byte) []byte
+
+type responseRewriter struct {
+	key          string
+	matchURL     func(url string) bool
+	resourceType WkeResourceType
+	byType       bool
+	fn           RewriteResponseFunc
+}
+
+// 在响应数据到达后改写，可多次调用组成处理链，按加入顺序依次执行，前一个的返回值作为后一个的 body
+//
+// matcher 支持以下类型，其他类型返回错误：
+//   - string：url 的通配符，* 匹配任意字符，? 匹配单个字符，如 "https://*.qq.com/*"
+//   - *regexp.Regexp：匹配 url
+//   - WkeResourceType：按 OnLoadUrlBegin 时 miniblink 给出的资源类型匹配，可区分 MAIN_FRAME 与 SUB_FRAME
+//   - func(url string) bool
+//
+// 匹配的请求会调用 NetHookRequest 缓存全部网络数据，影响性能，matcher 应尽量精确
+func (v *View) RewriteResponse(matcher interface{}, fn RewriteResponseFunc) (stop func(), err error) {
+	rewriter := &responseRewriter{key: utils.RandString(10), fn: fn}
+
+	switch m := matcher.(type) {
+	case string:
+		pattern, err := regexp.Compile("^" + utils.GlobToRegexp(m) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("RewriteResponse: invalid pattern %q: %w", m, err)
+		}
+		rewriter.matchURL = pattern.MatchString
+	case *regexp.Regexp:
+		rewriter.matchURL = m.MatchString
+	case WkeResourceType:
+		if m < WKE_RESOURCE_TYPE_MAIN_FRAME || m >= WKE_RESOURCE_TYPE_LAST_TYPE {
+			return nil, fmt.Errorf("RewriteResponse: unsupported resource type %d", m)
+		}
+		rewriter.resourceType, rewriter.byType = m, true
+	case func(url string) bool:
+		rewriter.matchURL = m
+	default:
+		return nil, fmt.Errorf("RewriteResponse: unsupported matcher type %T", matcher)
+	}
+
+	v._rewriters.locker.Lock()
+	v._rewriters.list = append(v._rewriters.list, rewriter)
+	v._rewriters.locker.Unlock()
+
+	v._rewriters.register.Do(v.registerRewriters)
+
+	return func() {
+		v._rewriters.locker.Lock()
+		defer v._rewriters.locker.Unlock()
+
+		for i, r := range v._rewriters.list {
+			if r.key == rewriter.key {
+				v._rewriters.list = append(v._rewriters.list[:i:i], v._rewriters.list[i+1:]...)
+				break
+			}
+		}
+	}, nil
+}
+
+type rewriters struct {
+	list     []*responseRewriter
+	locker   sync.Mutex
+	register sync.Once
+}
+
+func (rs *rewriters) snapshot() []*responseRewriter {
+	rs.locker.Lock()
+	defer rs.locker.Unlock()
+
+	return append([]*responseRewriter{}, rs.list...)
+}
+
+func (v *View) registerRewriters() {
+	v.OnLoadUrlBegin(func(url string, job WkeNetJob) bool {
+		for _, r := range v._rewriters.snapshot() {
+			if r.match(v, url, job) {
+				v.mb.NetHookRequest(job)
+				break
+			}
+		}
+		return false
+	})
+
+	v.OnLoadUrlEnd(func(url string, job WkeNetJob, buf []byte) {
+		resp := newResponse(v, job)
+
+		body, rewritten := buf, false
+		for _, r := range v._rewriters.snapshot() {
+			if !r.match(v, url, job) {
+				continue
+			}
+
+			if result, ok := r.rewrite(resp, body); ok {
+				body, rewritten = result, true
+			}
+		}
+
+		if rewritten {
+			v.mb.NetSetData(job, body)
+		}
+	})
+}
+
+func (r *responseRewriter) rewrite(resp *Response, body []byte) (result []byte, ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("RewriteResponse panic: %v", err)
+			result, ok = nil, false
+		}
+	}()
+
+	return r.fn(resp, body), true
+}
+
+// 资源类型为 OnLoadUrlBegin 时按 job 记录的值，未知时不匹配
+func (r *responseRewriter) match(v *View, url string, job WkeNetJob) bool {
+	if !r.byType {
+		return r.matchURL(url)
+	}
+
+	resourceType, ok := newRequest(v, job).ResourceType()
+	return ok && resourceType == r.resourceType
+}
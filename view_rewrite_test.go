@@ -0,0 +1,74 @@
+package blink_test
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/epkgs/blink"
+)
+
+func TestRewriteResponseRejectsUnsupportedMatchers(t *testing.T) {
+	app, _ := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	noop := func(resp *blink.Response, body []byte) []byte { return body }
+
+	for _, matcher := range []interface{}{42, blink.WKE_RESOURCE_TYPE_LAST_TYPE, blink.WkeResourceType(-1)} {
+		if stop, err := view.RewriteResponse(matcher, noop); err == nil || stop != nil {
+			t.Fatalf("RewriteResponse(%v) error = %v, want error", matcher, err)
+		}
+	}
+
+	stop, err := view.RewriteResponse(regexp.MustCompile(`\.js$`), noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+}
+
+func TestRewriteResponseByResourceType(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	var mu sync.Mutex
+	var current *blink.WkeTempCallbackInfo
+	fake.Handle("wkeGetTempCallbackInfo", func(args ...uintptr) uintptr {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return uintptr(unsafe.Pointer(current))
+	})
+
+	begin := func(url string, job blink.WkeNetJob, resourceType blink.WkeResourceType) {
+		mu.Lock()
+		current = &blink.WkeTempCallbackInfo{Job: job, WillSendRequestInfo: &blink.WkeWillSendRequestInfo{ResourceType: resourceType}}
+		mu.Unlock()
+
+		fireLoadUrlBegin(fake, url, job)
+	}
+
+	_, err := view.RewriteResponse(blink.WKE_RESOURCE_TYPE_SUB_FRAME, func(resp *blink.Response, body []byte) []byte {
+		return append(body, "<!-- rewritten -->"...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 主 frame 与子 frame 的 MIME 相同，按 OnLoadUrlBegin 时的资源类型区分
+	begin("http://page.test/", 1, blink.WKE_RESOURCE_TYPE_MAIN_FRAME)
+	begin("http://page.test/frame.html", 2, blink.WKE_RESOURCE_TYPE_SUB_FRAME)
+
+	if calls := fake.Calls("wkeNetHookRequest"); len(calls) != 1 || calls[0].Args[0] != 2 {
+		t.Fatalf("wkeNetHookRequest calls = %v", calls)
+	}
+
+	body := fake.String("<html></html>")
+	fake.FireView("wkeOnLoadUrlEnd", testViewHandle, fake.String("http://page.test/"), 1, body, 13)
+	fake.FireView("wkeOnLoadUrlEnd", testViewHandle, fake.String("http://page.test/frame.html"), 2, body, 13)
+
+	if calls := fake.Calls("wkeNetSetData"); len(calls) != 1 || calls[0].Args[0] != 2 {
+		t.Fatalf("wkeNetSetData calls = %v", calls)
+	}
+}
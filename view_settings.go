@@ -0,0 +1,151 @@
+package blink
+
+import (
+	"math"
+	"strings"
+)
+
+// 模拟的设备参数，用于移动端预览
+type Device struct {
+	Name             string
+	UserAgent        string
+	Width            int32 // 屏幕宽度，CSS 像素
+	Height           int32 // 屏幕高度，CSS 像素
+	DevicePixelRatio float32
+	Platform         string // navigator.platform
+	MaxTouchPoints   int    // 大于 0 时开启触屏模式，鼠标消息会转换为触屏消息
+}
+
+const (
+	uaIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1"
+	uaIPad    = "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1"
+	uaPixel   = "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36"
+	uaGalaxy  = "Mozilla/5.0 (Linux; Android 13; SM-G981B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36"
+	uaDesktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36"
+)
+
+// 常用设备预设，参数与 Chrome DevTools 的设备列表一致
+var Devices = []Device{
+	{Name: "iPhone SE", UserAgent: uaIPhone, Width: 375, Height: 667, DevicePixelRatio: 2, Platform: "iPhone", MaxTouchPoints: 5},
+	{Name: "iPhone 12 Pro", UserAgent: uaIPhone, Width: 390, Height: 844, DevicePixelRatio: 3, Platform: "iPhone", MaxTouchPoints: 5},
+	{Name: "iPhone 14 Pro Max", UserAgent: uaIPhone, Width: 430, Height: 932, DevicePixelRatio: 3, Platform: "iPhone", MaxTouchPoints: 5},
+	{Name: "Pixel 7", UserAgent: uaPixel, Width: 412, Height: 915, DevicePixelRatio: 2.625, Platform: "Linux armv8l", MaxTouchPoints: 5},
+	{Name: "Samsung Galaxy S20 Ultra", UserAgent: uaGalaxy, Width: 412, Height: 915, DevicePixelRatio: 3.5, Platform: "Linux armv8l", MaxTouchPoints: 5},
+	{Name: "iPad Mini", UserAgent: uaIPad, Width: 768, Height: 1024, DevicePixelRatio: 2, Platform: "iPad", MaxTouchPoints: 5},
+	{Name: "iPad Air", UserAgent: uaIPad, Width: 820, Height: 1180, DevicePixelRatio: 2, Platform: "iPad", MaxTouchPoints: 5},
+	{Name: "Desktop 1080p", UserAgent: uaDesktop, Width: 1920, Height: 1080, DevicePixelRatio: 1, Platform: "Win32", MaxTouchPoints: 0},
+}
+
+// 按名称查找设备预设，不区分大小写
+func DeviceByName(name string) (Device, bool) {
+	for _, device := range Devices {
+		if strings.EqualFold(device.Name, name) {
+			return device, true
+		}
+	}
+	return Device{}, false
+}
+
+// view 的设置，由 Config 的 With... 设置全局默认值，在 NewView 时应用
+type viewSettings struct {
+	userAgent  string
+	language   string
+	device     *Device
+	zoomFactor float32
+	cspCheck   *bool
+}
+
+func (v *View) applySettings(settings viewSettings) {
+	// 设备参数包含 UserAgent，需在 UserAgent 之前设置，使单独设置的 UserAgent 优先
+	if settings.device != nil {
+		v.SetDevice(*settings.device)
+	}
+	if settings.userAgent != "" {
+		v.SetUserAgent(settings.userAgent)
+	}
+	if settings.language != "" {
+		v.SetLanguage(settings.language)
+	}
+	if settings.zoomFactor > 0 {
+		v.SetZoomFactor(settings.zoomFactor)
+	}
+	if settings.cspCheck != nil {
+		v.SetCSPCheckEnabled(*settings.cspCheck)
+	}
+}
+
+func (v *View) SetUserAgent(userAgent string) {
+	_, _, _ = v.mb.CallFunc("wkeSetUserAgent", uintptr(v.Hwnd), StringToPtr(userAgent))
+}
+
+func (v *View) GetUserAgent() string {
+	p, _, _ := v.mb.CallFunc("wkeGetUserAgent", uintptr(v.Hwnd))
+	return PtrToString(p)
+}
+
+// 设置 navigator.language，如 zh-CN
+func (v *View) SetLanguage(language string) {
+	_, _, _ = v.mb.CallFunc("wkeSetLanguage", uintptr(v.Hwnd), StringToPtr(language))
+}
+
+// 设置页面缩放比例，1 为不缩放
+func (v *View) SetZoomFactor(factor float32) {
+	_, _, _ = v.mb.CallFunc("wkeSetZoomFactor", uintptr(v.Hwnd), uintptr(math.Float32bits(factor)))
+	v.zoomFactor = factor
+}
+
+// 获取通过 SetZoomFactor 设置的缩放比例。wkeGetZoomFactor 的浮点返回值无法通过 syscall 获取，因此记录在 view 上
+func (v *View) GetZoomFactor() float32 {
+	if v.zoomFactor <= 0 {
+		return 1
+	}
+	return v.zoomFactor
+}
+
+// 是否检查 CSP（Content-Security-Policy）
+func (v *View) SetCSPCheckEnabled(enable bool) {
+	_, _, _ = v.mb.CallFunc("wkeSetCspCheckEnable", uintptr(v.Hwnd), BoolToPtr(enable))
+}
+
+// 开启触屏模式，鼠标消息会转换为触屏消息
+func (v *View) SetTouchEnabled(enable bool) {
+	_, _, _ = v.mb.CallFunc("wkeSetTouchEnabled", uintptr(v.Hwnd), BoolToPtr(enable))
+}
+
+// 设置 navigator、screen 等设备参数，device 支持：
+//
+//	navigator.maxTouchPoints      paramInt
+//	navigator.platform            paramStr
+//	navigator.hardwareConcurrency paramInt
+//	screen.width                  paramInt
+//	screen.height                 paramInt
+//	screen.availWidth             paramInt
+//	screen.availHeight            paramInt
+//	screen.pixelDepth             paramInt
+//	window.devicePixelRatio       paramFloat
+func (v *View) SetDeviceParameter(device, paramStr string, paramInt int, paramFloat float32) {
+	_, _, _ = v.mb.CallFunc("wkeSetDeviceParameter", uintptr(v.Hwnd), StringToPtr(device), StringToPtr(paramStr), uintptr(paramInt), uintptr(math.Float32bits(paramFloat)))
+}
+
+// 模拟设备，设置 UserAgent、屏幕尺寸、devicePixelRatio 及触屏模式
+//
+// 不会改变 view 的大小，需要时可调用 Resize(device.Width, device.Height)
+func (v *View) SetDevice(device Device) {
+	if device.UserAgent != "" {
+		v.SetUserAgent(device.UserAgent)
+	}
+	if device.Platform != "" {
+		v.SetDeviceParameter("navigator.platform", device.Platform, 0, 0)
+	}
+	if device.Width > 0 && device.Height > 0 {
+		v.SetDeviceParameter("screen.width", "", int(device.Width), 0)
+		v.SetDeviceParameter("screen.height", "", int(device.Height), 0)
+		v.SetDeviceParameter("screen.availWidth", "", int(device.Width), 0)
+		v.SetDeviceParameter("screen.availHeight", "", int(device.Height), 0)
+	}
+	if device.DevicePixelRatio > 0 {
+		v.SetDeviceParameter("window.devicePixelRatio", "", 0, device.DevicePixelRatio)
+	}
+	v.SetDeviceParameter("navigator.maxTouchPoints", "", device.MaxTouchPoints, 0)
+	v.SetTouchEnabled(device.MaxTouchPoints > 0)
+}
@@ -0,0 +1,256 @@
+package blink
+
+import (
+	"sync"
+	"time"
+)
+
+// 进行中的网络请求，用于 WaitNetworkIdle
+//
+// 在 OnLoadUrlBegin 的所有回调之前记录，被回调拦截（直接返回数据或取消）的请求在回调返回时结束，
+// 被 OnRequestAsync 挂起的请求在提交时结束或继续由网络层加载，其余请求在 OnLoadUrlEnd / Finish / Fail 时结束。
+// 不交给网络层的请求没有 OnLoadUrlEnd 等事件，结束时通知 netObserver
+type netJobTracker struct {
+	locker       sync.Mutex
+	jobs         map[WkeNetJob]bool // job -> 是否被挂起
+	lastActivity time.Time
+}
+
+func (t *netJobTracker) touch() {
+	t.lastActivity = time.Now()
+}
+
+func (t *netJobTracker) begin(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if t.jobs == nil {
+		t.jobs = make(map[WkeNetJob]bool)
+	}
+	t.jobs[job] = false
+	t.touch()
+}
+
+// 请求被挂起，等待异步提交
+func (t *netJobTracker) hold(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if _, exist := t.jobs[job]; exist {
+		t.jobs[job] = true
+	}
+}
+
+// 挂起的请求放行，继续由网络层加载
+func (t *netJobTracker) release(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if _, exist := t.jobs[job]; exist {
+		t.jobs[job] = false
+	}
+	t.touch()
+}
+
+// OnLoadUrlBegin 的回调返回 true，请求不会交给网络层，返回 false 表示请求被挂起，尚未结束
+func (t *netJobTracker) handled(job WkeNetJob) (ended bool) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	t.touch()
+
+	held, exist := t.jobs[job]
+	return exist && !held
+}
+
+func (t *netJobTracker) end(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	delete(t.jobs, job)
+	t.touch()
+}
+
+// 进行中的请求数及最后一次请求开始、结束的时间
+func (t *netJobTracker) state() (inflight int, lastActivity time.Time) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	return len(t.jobs), t.lastActivity
+}
+
+// 按 job 记录的请求信息，在 OnLoadUrlBegin 的回调之前创建，请求结束且全部回调执行后清除
+type netJobInfo struct {
+	resourceType    WkeResourceType
+	hasResourceType bool
+	statusCode      int // 来自 OnOtherLoad 的 WKE_DID_GET_RESPONSE_DETAILS / WKE_DID_GET_REDIRECT_REQUEST，0 表示未知
+}
+
+type netJobInfos struct {
+	locker sync.Mutex
+	infos  map[WkeNetJob]*netJobInfo
+}
+
+func (t *netJobInfos) begin(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if t.infos == nil {
+		t.infos = make(map[WkeNetJob]*netJobInfo)
+	}
+	t.infos[job] = &netJobInfo{}
+}
+
+// 修改记录，job 不存在（如在 NewView 之前发起的请求）时忽略
+func (t *netJobInfos) update(job WkeNetJob, fn func(info *netJobInfo)) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if info, exist := t.infos[job]; exist {
+		fn(info)
+	}
+}
+
+func (t *netJobInfos) get(job WkeNetJob) netJobInfo {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if info, exist := t.infos[job]; exist {
+		return *info
+	}
+	return netJobInfo{}
+}
+
+func (t *netJobInfos) forget(job WkeNetJob) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	delete(t.infos, job)
+}
+
+// 观察全部请求，不受 OnLoadUrlBegin 回调拦截的影响，如 HAR 记录
+type netObserver struct {
+	begin  func(url string, job WkeNetJob)            // 在 OnLoadUrlBegin 的回调之前
+	served func(job WkeNetJob, rec *responseRecorder) // 由 Resource、AsyncJob.Respond 等直接返回数据
+	ended  func(job WkeNetJob)                        // 不交给网络层的请求结束，包括直接返回数据和取消
+}
+
+func (v *View) observeNetJobs(observer *netObserver) (stop func()) {
+	return v._netObservers.Add(observer)
+}
+
+func (v *View) beginNetJob(url string, job WkeNetJob) {
+	v._netJobs.begin(job)
+	v._jobInfos.begin(job)
+
+	// wkeGetTempCallbackInfo 只在 OnLoadUrlBegin 期间有效，之后通过 Request.ResourceType 读取记录的值
+	if resourceType, ok := v.tempResourceType(job); ok {
+		v._jobInfos.update(job, func(i *netJobInfo) { i.resourceType, i.hasResourceType = resourceType, true })
+	}
+
+	for _, observer := range v._netObservers.Callbacks() {
+		observer.begin(url, job)
+	}
+}
+
+func (v *View) serveNetJob(job WkeNetJob, rec *responseRecorder) {
+	for _, observer := range v._netObservers.Callbacks() {
+		observer.served(job, rec)
+	}
+}
+
+func (v *View) endNetJob(job WkeNetJob) {
+	v._netJobs.end(job)
+
+	for _, observer := range v._netObservers.Callbacks() {
+		observer.ended(job)
+	}
+
+	v._jobInfos.forget(job)
+}
+
+// 交给网络层的请求由 OnLoadUrlEnd / Finish / Fail 结束，需要在其他回调之前注册
+func (v *View) trackNetJobs() {
+	v.registerLoadUrlBegin()
+	v.OnLoadUrlEnd(func(url string, job WkeNetJob, buf []byte) { v._netJobs.end(job) })
+	v.OnLoadUrlFinish(func(url string, job WkeNetJob, length int) { v._netJobs.end(job) })
+	v.OnLoadUrlFail(func(url string, job WkeNetJob) { v._netJobs.end(job) })
+
+	// 跳转时先收到 3xx，跳转后的响应再覆盖
+	v.OnOtherLoad(func(loadType WkeOtherLoadType, info *WkeTempCallbackInfo) {
+		if loadType != WKE_DID_GET_RESPONSE_DETAILS && loadType != WKE_DID_GET_REDIRECT_REQUEST {
+			return
+		}
+		if info == nil || info.WillSendRequestInfo == nil {
+			return
+		}
+
+		code := int(info.WillSendRequestInfo.HTTPResponseCode)
+		v._jobInfos.update(info.Job, func(i *netJobInfo) { i.statusCode = code })
+	})
+}
+
+// 通过 LoadURL、LoadHTML、Reload 等发起、尚未完成的主 frame 加载
+//
+// 刚发起加载时 IsDocumentReady、IsLoadingCompleted 仍是上一个页面的状态，需要等到新的加载有结果
+type loadTracker struct {
+	locker    sync.Mutex
+	documents int // 尚未 document ready 的加载
+	loads     int // 尚未触发 OnLoadingFinish 的加载
+}
+
+func (t *loadTracker) start() {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	t.documents++
+	t.loads++
+}
+
+// 加载没有发起，如无法后退
+func (t *loadTracker) abort() {
+	t.finish(WKE_LOADING_CANCELED)
+}
+
+func (t *loadTracker) documentReady() {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	t.documents = 0
+}
+
+// 成功时之前的加载都已结束；失败、取消（如被新的加载打断）时只结束一个
+func (t *loadTracker) finish(result WkeLoadingResult) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	if result == WKE_LOADING_SUCCEEDED {
+		t.documents, t.loads = 0, 0
+		return
+	}
+
+	if t.documents > 0 {
+		t.documents--
+	}
+	if t.loads > 0 {
+		t.loads--
+	}
+}
+
+func (t *loadTracker) pending() (documents, loads int) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+
+	return t.documents, t.loads
+}
+
+func (v *View) trackLoads() {
+	v.OnDocumentReady(func(frame WkeWebFrameHandle) {
+		if v.IsMainFrame(frame) {
+			v._loads.documentReady()
+		}
+	})
+	v.OnLoadingFinish(func(url string, result WkeLoadingResult, failedReason string) {
+		v._loads.finish(result)
+	})
+}
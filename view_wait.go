@@ -0,0 +1,222 @@
+package blink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/epkgs/blink/pkg/utils"
+)
+
+// 需要执行 js 或按时间判断的等待条件的轮询间隔
+const waitPollInterval = 100 * time.Millisecond
+
+var ErrWaitOnUIThread = errors.New("cannot wait on the miniblink thread")
+
+// 等待条件，由 WaitDocumentReady、WaitNetworkIdle 等函数创建，用于 View.WaitFor
+type WaitCondition struct {
+	name string
+
+	// 注册相关事件，事件发生时调用 notify 重新检查，返回 check 与取消注册的 stop
+	setup func(v *View, notify func()) (check func() bool, stop func())
+
+	// 是否需要定时轮询
+	poll bool
+
+	// 创建条件时的错误，如不支持的 matcher，由 WaitFor 返回
+	err error
+}
+
+func (c WaitCondition) String() string {
+	return c.name
+}
+
+// 阻塞等待条件满足，ctx 结束时返回包含 ctx.Err() 的错误
+//
+// 条件的检查需要 miniblink 线程处理事件，不能在 miniblink 线程（如 view 的事件回调）中调用
+func (v *View) WaitFor(ctx context.Context, condition WaitCondition) error {
+	if condition.err != nil {
+		return fmt.Errorf("wait for %s: %w", condition.name, condition.err)
+	}
+
+	if v.mb.threadID == currentThreadID() {
+		return ErrWaitOnUIThread
+	}
+
+	notifyCh := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case notifyCh <- struct{}{}:
+		default:
+		}
+	}
+
+	var check func() bool
+	var stop func()
+	<-v.mb.AddJob(func() {
+		check, stop = condition.setup(v, notify)
+	})
+	defer v.mb.AddJob(stop)
+
+	var tick <-chan time.Time
+	if condition.poll {
+		ticker := time.NewTicker(waitPollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if check() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for %s: %w", condition.name, ctx.Err())
+		case <-notifyCh:
+		case <-tick:
+		}
+	}
+}
+
+// 主 frame 的 document ready，在 LoadURL 等发起加载后调用时等待新页面
+func WaitDocumentReady() WaitCondition {
+	return WaitCondition{
+		name: "document ready",
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			stops := []func(){
+				v.OnDocumentReady(func(frame WkeWebFrameHandle) {
+					if v.IsMainFrame(frame) {
+						notify()
+					}
+				}),
+				v.OnLoadingFinish(func(url string, result WkeLoadingResult, failedReason string) { notify() }),
+			}
+
+			check := func() bool {
+				documents, _ := v._loads.pending()
+				return documents == 0 && v.IsDocumentReady()
+			}
+			return check, func() {
+				for _, stop := range stops {
+					stop()
+				}
+			}
+		},
+	}
+}
+
+// 页面加载结束，包括加载失败，在 LoadURL 等发起加载后调用时等待新页面
+func WaitLoadFinished() WaitCondition {
+	return WaitCondition{
+		name: "load finished",
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			stop := v.OnLoadingFinish(func(url string, result WkeLoadingResult, failedReason string) {
+				notify()
+			})
+
+			check := func() bool {
+				if _, loads := v._loads.pending(); loads > 0 {
+					return false
+				}
+				return v.IsLoadingCompleted() && !v.IsLoading()
+			}
+			return check, stop
+		},
+	}
+}
+
+// 网络空闲，即持续 idle 时间没有进行中的请求，包括 WaitFor 调用之前发出的请求
+func WaitNetworkIdle(idle time.Duration) WaitCondition {
+	return WaitCondition{
+		name: "network idle " + idle.String(),
+		poll: true,
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			since := time.Now()
+
+			check := func() bool {
+				inflight, lastActivity := v._netJobs.state()
+				if lastActivity.After(since) {
+					since = lastActivity
+				}
+				return inflight == 0 && time.Since(since) >= idle
+			}
+			return check, func() {}
+		},
+	}
+}
+
+// 主 frame 中存在匹配 selector 的元素
+func WaitSelector(selector string) WaitCondition {
+	return WaitJS("document.querySelector(" + strconv.Quote(selector) + ")")
+}
+
+// 主 frame 中 js 表达式的值为真，表达式抛出异常时视为假
+func WaitJS(expression string) WaitCondition {
+	script := "return (function(){try{return !!(" + expression + ")}catch(e){return false}})()"
+
+	return WaitCondition{
+		name: "js " + expression,
+		poll: true,
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			check := func() bool {
+				var ok bool
+				<-v.mb.AddJob(func() {
+					es := v.mb.js.GlobalExec(v.Hwnd)
+					ok = v.mb.js.ToBoolean(es, v.RunJs(script))
+				})
+				return ok
+			}
+			return check, v.OnDocumentReady(func(frame WkeWebFrameHandle) { notify() })
+		},
+	}
+}
+
+// 页面标题匹配，matcher 为 string 时按通配符匹配（* 任意字符，? 单个字符），也可以是 *regexp.Regexp、func(string) bool，
+// 其他类型时 WaitFor 返回错误
+func WaitTitle(matcher interface{}) WaitCondition {
+	match, err := textMatcher(matcher)
+
+	return WaitCondition{
+		name: fmt.Sprintf("title %v", matcher),
+		err:  err,
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			stop := v.OnTitleChanged(func(title string) { notify() })
+			return func() bool { return match(v.GetTitle()) }, stop
+		},
+	}
+}
+
+// 主 frame 的 url 匹配，matcher 同 WaitTitle
+func WaitURL(matcher interface{}) WaitCondition {
+	match, err := textMatcher(matcher)
+
+	return WaitCondition{
+		name: fmt.Sprintf("url %v", matcher),
+		err:  err,
+		setup: func(v *View, notify func()) (func() bool, func()) {
+			stop := v.OnURLChanged(func(frame WkeWebFrameHandle, url string) { notify() })
+			return func() bool { return match(v.GetURL()) }, stop
+		},
+	}
+}
+
+func textMatcher(matcher interface{}) (func(string) bool, error) {
+	switch m := matcher.(type) {
+	case string:
+		pattern, err := regexp.Compile("^" + utils.GlobToRegexp(m) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", m, err)
+		}
+		return pattern.MatchString, nil
+	case *regexp.Regexp:
+		return m.MatchString, nil
+	case func(string) bool:
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported matcher type %T", matcher)
+	}
+}
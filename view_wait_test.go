@@ -0,0 +1,75 @@
+package blink_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/epkgs/blink"
+)
+
+func waitFor(view *blink.View, condition blink.WaitCondition, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return view.WaitFor(ctx, condition)
+}
+
+func TestWaitNetworkIdle(t *testing.T) {
+	app, fake := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	// 被其他回调拦截的请求没有后续事件，直接结束
+	view.OnLoadUrlBegin(func(url string, job blink.WkeNetJob) bool {
+		return url == "http://served.test/"
+	})
+
+	fireLoadUrlBegin(fake, "http://served.test/", 1)
+	if err := waitFor(view, blink.WaitNetworkIdle(50*time.Millisecond), time.Second); err != nil {
+		t.Fatalf("served request kept network busy: %v", err)
+	}
+
+	fireLoadUrlBegin(fake, "http://network.test/", 2)
+	if err := waitFor(view, blink.WaitNetworkIdle(50*time.Millisecond), 300*time.Millisecond); err == nil {
+		t.Fatal("network idle while a request is in flight")
+	}
+
+	fake.FireView("wkeOnLoadUrlFinish", testViewHandle, 0, 2, 100)
+	if err := waitFor(view, blink.WaitNetworkIdle(50*time.Millisecond), time.Second); err != nil {
+		t.Fatalf("network not idle after request finished: %v", err)
+	}
+}
+
+func TestWaitDocumentReadyAfterLoadURL(t *testing.T) {
+	app, fake := newTestApp(t)
+	fake.Return("wkeIsDocumentReady", 1)
+	fake.Return("wkeIsMainFrame", 1)
+	view := app.CreateWebWindowPopup()
+
+	if err := waitFor(view, blink.WaitDocumentReady(), time.Second); err != nil {
+		t.Fatalf("document ready: %v", err)
+	}
+
+	// 上一个页面的状态不能让新的加载立即满足条件
+	view.LoadURL("http://next.test/")
+	if err := waitFor(view, blink.WaitDocumentReady(), 300*time.Millisecond); err == nil {
+		t.Fatal("WaitDocumentReady returned for the previous document")
+	}
+
+	fake.FireView("wkeOnDocumentReady2", testViewHandle, 7)
+	if err := waitFor(view, blink.WaitDocumentReady(), time.Second); err != nil {
+		t.Fatalf("document ready after load: %v", err)
+	}
+}
+
+func TestWaitUnsupportedMatcher(t *testing.T) {
+	app, _ := newTestApp(t)
+	view := app.CreateWebWindowPopup()
+
+	for _, condition := range []blink.WaitCondition{blink.WaitTitle(42), blink.WaitURL(nil)} {
+		if err := waitFor(view, condition, time.Second); err == nil || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("WaitFor(%v) = %v, want matcher error", condition, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package blink
+
+// 非 windows 平台没有原生窗口，仅通过 miniblink 的导出函数控制显示，用于配合 WithNativeBackend 测试
+type Window struct {
+	mb   *Blink
+	view *View
+	Hwnd WkeHandle
+
+	windowType  WkeWindowType
+	isMaximized bool
+}
+
+func newWindow(mb *Blink, view *View, windowType WkeWindowType) *Window {
+	return &Window{
+		mb:         mb,
+		view:       view,
+		windowType: windowType,
+		Hwnd:       view.GetWindowHandle(),
+	}
+}
+
+func (w *Window) Show() {
+	_, _, _ = w.mb.CallFunc("wkeShowWindow", uintptr(w.view.Hwnd), BoolToPtr(true))
+}
+
+func (w *Window) Hide() {
+	_, _, _ = w.mb.CallFunc("wkeShowWindow", uintptr(w.view.Hwnd), BoolToPtr(false))
+}
+
+func (w *Window) Close() {
+	w.view.DestroyWindow()
+}
+
+func (w *Window) Destroy() {
+	w.view.DestroyWindow()
+}
+
+func (w *Window) Minimize() {}
+
+func (w *Window) Maximize() {
+	w.isMaximized = true
+}
+
+func (w *Window) IsMaximized() bool {
+	return w.isMaximized
+}
+
+func (w *Window) Restore() {
+	w.isMaximized = false
+}
+
+func (w *Window) EnableDragging() {}
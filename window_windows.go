@@ -1,3 +1,5 @@
+//go:build windows
+
 package blink
 
 import (
@@ -11,7 +13,6 @@ import (
 	"unsafe"
 
 	"github.com/epkgs/blink/internal/log"
-	"github.com/epkgs/blink/pkg/utils"
 	"github.com/lxn/win"
 )
 
@@ -209,7 +210,7 @@ func (w *Window) hookWindowProc(hwnd, message, wparam, lparam uintptr) uintptr {
 
 			rect := (*win.RECT)(unsafe.Pointer(lparam))
 
-			for _, cb := range w._onSizing.Callbacks {
+			for _, cb := range w._onSizing.Callbacks() {
 				cb(pos, rect)
 			}
 
@@ -217,7 +218,7 @@ func (w *Window) hookWindowProc(hwnd, message, wparam, lparam uintptr) uintptr {
 			stype := (SIZE_TYPE)(wparam)
 			width := LOWORD(uint32(lparam))
 			height := HIWORD(uint32(lparam))
-			for _, cb := range w._onSize.Callbacks {
+			for _, cb := range w._onSize.Callbacks() {
 				cb(stype, width, height)
 			}
 
@@ -226,14 +227,14 @@ func (w *Window) hookWindowProc(hwnd, message, wparam, lparam uintptr) uintptr {
 			log.Debug("trigger WM_CREATE")
 			created := (*win.CREATESTRUCT)(unsafe.Pointer(lparam))
 
-			for _, cb := range w._onCreate.Callbacks {
+			for _, cb := range w._onCreate.Callbacks() {
 				cb(created)
 			}
 
 		case win.WM_ACTIVATEAPP:
 			actived := wparam == 1
 
-			for _, cb := range w._onActivateApp.Callbacks {
+			for _, cb := range w._onActivateApp.Callbacks() {
 				cb(actived, lparam)
 			}
 
@@ -597,38 +598,20 @@ func (w *Window) Move(x, y int32) {
 
 // Sizing 事件
 func (w *Window) OnSizing(callback WindowOnSizingCallback) (stop func()) {
-	key := utils.RandString(6)
-	w._onSizing.Callbacks[key] = callback
-
-	return func() {
-		delete(w._onSizing.Callbacks, key)
-	}
+	return w._onSizing.Add(callback)
 }
 
 // Size 事件
 func (w *Window) OnSize(callback WindowOnSizeCallback) (stop func()) {
-	key := utils.RandString(6)
-	w._onSize.Callbacks[key] = callback
-
-	return func() {
-		delete(w._onSize.Callbacks, key)
-	}
+	return w._onSize.Add(callback)
 }
 
 // Create 事件
 func (w *Window) OnCreate(callback WindowOnCreateCallback) (stop func()) {
-	key := utils.RandString(6)
-	w._onCreate.Callbacks[key] = callback
-	return func() {
-		delete(w._onCreate.Callbacks, key)
-	}
+	return w._onCreate.Add(callback)
 }
 
 // Active APP 事件
 func (w *Window) OnActivateApp(callback WindowOnActivateAppCallback) (stop func()) {
-	key := utils.RandString(6)
-	w._onActivateApp.Callbacks[key] = callback
-	return func() {
-		delete(w._onActivateApp.Callbacks, key)
-	}
+	return w._onActivateApp.Add(callback)
 }
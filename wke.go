@@ -50,7 +50,7 @@ type JsKeys struct {
 type WkeRequestType int
 
 const (
-	WkeRequestType_Unknow WkeRequestType = iota + 1
+	WkeRequestType_Unknow WkeRequestType = iota
 	WkeRequestType_Get
 	WkeRequestType_Post
 	WkeRequestType_Put
@@ -242,6 +242,14 @@ type wkePdfDatas struct {
 	datas uintptr // 二进制数据
 }
 
+type WkeLoadingResult int
+
+const (
+	WKE_LOADING_SUCCEEDED WkeLoadingResult = iota
+	WKE_LOADING_FAILED
+	WKE_LOADING_CANCELED
+)
+
 type WkeOtherLoadType int
 
 const (
@@ -264,7 +272,7 @@ type WkeTempCallbackInfo struct {
 }
 
 // 枚举类型
-type WkeResourceType int
+type WkeResourceType int32
 
 const (
 	WKE_RESOURCE_TYPE_MAIN_FRAME WkeResourceType = iota
@@ -290,7 +298,7 @@ type WkeWillSendRequestInfo struct {
 	URL              WkeString
 	NewURL           WkeString
 	ResourceType     WkeResourceType
-	HTTPResponseCode int
+	HTTPResponseCode int32
 	Method           WkeString
 	Referrer         WkeString
 	Headers          unsafe.Pointer // 使用unsafe.Pointer代替C中的void*
@@ -304,6 +312,19 @@ const (
 	WkeHttBodyElementTypeFile
 )
 
+// jsExceptionInfo 结构体
+type JsExceptionInfo struct {
+	Message            uintptr // const utf8*
+	SourceLine         uintptr // const utf8*
+	ScriptResourceName uintptr // const utf8*
+	LineNumber         int32
+	StartPosition      int32
+	EndPosition        int32
+	StartColumn        int32
+	EndColumn          int32
+	CallstackString    uintptr // const utf8*
+}
+
 // wkeMemBuf 结构体
 type WkeMemBuf struct {
 	Unuse  int